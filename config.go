@@ -2,45 +2,173 @@ package logzap
 
 import (
 	"fmt"
+	"path"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/pflag"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
 )
 
 var (
+	ErrInvalidLevel       = fmt.Errorf("invalid level")
 	ErrInvalidModuleLevel = fmt.Errorf("invalid module level")
 	ErrUnsupportedFields  = fmt.Errorf("unsupported fields")
+	ErrInvalidSampling    = fmt.Errorf("invalid sampling")
 )
 
 type Config struct {
-	Level   zapcore.Level `yaml:"level"`
-	Modules ModulesLevel  `yaml:"modules,omitempty"`
-	Cores   Cores         `yaml:"cores,omitempty"`
+	Level    zapcore.Level   `yaml:"level"`
+	Modules  ModulesLevel    `yaml:"modules,omitempty"`
+	Cores    Cores           `yaml:"cores,omitempty"`
+	Sampling ModulesSampling `yaml:"sampling,omitempty"`
+	Metrics  MetricsConfig   `yaml:"metrics,omitempty"`
+}
+
+// MetricsConfig enables the metrics which cost on every entry.
+type MetricsConfig struct {
+	// Bytes counts logzap_bytes_total, an estimate which encodes every entry
+	// again in JSON for each core.
+	Bytes bool `yaml:"bytes"`
 }
 
 func (t Config) RegisterFlagsWithPrefix(prefix string, f *pflag.FlagSet) {
 	f.String(prefix+".level", zapcore.DebugLevel.String(), "Global logger verbose level.")
 }
 
+// Validate checks the levels and cores of configuration.
+func (t Config) Validate() error {
+	if t.Level > zapcore.FatalLevel {
+		return fmt.Errorf("%w: %s", ErrInvalidLevel, t.Level)
+	}
+	for name, lv := range t.Modules {
+		if lv > zapcore.FatalLevel {
+			return fmt.Errorf("%w: %s: %s", ErrInvalidModuleLevel, name, lv)
+		}
+		if _, err := path.Match(name, ""); err != nil {
+			return fmt.Errorf("%w: %s: %s", ErrInvalidModuleLevel, name, err)
+		}
+	}
+
+	for name, sampling := range t.Sampling {
+		if sampling.Initial < 0 || sampling.Thereafter < 0 || sampling.Tick < 0 {
+			return fmt.Errorf("%w: %s: %+v", ErrInvalidSampling, name, sampling)
+		}
+		if sampling.Initial == 0 && sampling.Thereafter == 0 {
+			return fmt.Errorf("%w: %s: initial and thereafter are both 0, which drops every entry",
+				ErrInvalidSampling, name)
+		}
+		if _, err := path.Match(name, ""); err != nil {
+			return fmt.Errorf("%w: %s: %s", ErrInvalidSampling, name, err)
+		}
+	}
+
+	return t.Cores.Validate()
+}
+
+// DecodeConfig decodes YAML into configuration with MapStructureLevelDecodeHook,
+// unknown fields are rejected.
+func DecodeConfig(b []byte) (c Config, err error) {
+	raw := map[string]interface{}{}
+	if err = yaml.Unmarshal(b, &raw); err != nil {
+		return c, err
+	}
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(append(
+			[]mapstructure.DecodeHookFunc{mapstructure.StringToTimeDurationHookFunc()},
+			MapStructureLevelDecodeHook...)...),
+		ErrorUnused: true,
+		Result:      &c,
+	})
+	if err != nil {
+		return c, err
+	}
+	err = dec.Decode(raw)
+
+	return
+}
+
 type ModulesLevel map[string]zapcore.Level
 
 func (t ModulesLevel) Get(s string) (l zapcore.Level) {
-	if l, ok := t[strings.ToLower(s)]; ok {
+	if l, ok := t.Lookup(s); ok {
 		return l
 	}
 
 	return l
 }
 
-func (t ModulesLevel) build(log *zap.Logger) map[string]*logger {
-	m := map[string]*logger{}
+// Lookup return the level of the most specific rule which applies to module.
+//
+// A rule is a module name, which applies to the module itself and all of its
+// submodules, or a glob pattern of path.Match such as "db.*". The rule with
+// more literal characters is more specific, a module name wins a pattern with
+// the same specificity, and the less verbose level wins a tie. So
+// "parent.child.grandchild" inherits the level of "parent.child" unless it is
+// configured itself.
+func (t ModulesLevel) Lookup(module string) (lv zapcore.Level, ok bool) {
+	return lookupRule(t, module, func(a, b zapcore.Level) bool { return a > b })
+}
+
+// lookupRule return the value of the most specific rule which applies to
+// module as described in ModulesLevel.Lookup, prefer breaks the tie of rules
+// with the same specificity, and then the rule name does.
+func lookupRule[V any](rules map[string]V, module string, prefer func(a, b V) bool) (v V, ok bool) {
+	module = strings.ToLower(module)
+	if v, ok = rules[module]; ok {
+		return v, true
+	}
+	best, bestGlob, bestRule := -1, false, ""
+	for rule, value := range rules {
+		if !matchModule(rule, module) {
+			continue
+		}
+		score, glob := literalLen(rule), isGlob(rule)
+		better := score > best || (score == best && bestGlob && !glob)
+		if score == best && glob == bestGlob {
+			better = prefer(value, v) || (!prefer(v, value) && rule < bestRule)
+		}
+		if better {
+			best, bestGlob, bestRule, v, ok = score, glob, rule, value, true
+		}
+	}
+
+	return
+}
+
+func (t ModulesLevel) normalize() ModulesLevel {
+	m := make(ModulesLevel, len(t))
 	for k, lv := range t {
-		name := strings.ToLower(k)
-		m[name] = newLogger(log.Named(name), lv)
+		m[strings.ToLower(k)] = lv
+	}
+
+	return m
+}
+
+// Sampling caps the entries of a module with the same level and message to
+// Initial per Tick, and then every Thereafter-th one, see zapcore.NewSamplerWithOptions.
+type Sampling struct {
+	Initial    int           `yaml:"initial"`
+	Thereafter int           `yaml:"thereafter"`
+	Tick       time.Duration `yaml:"tick,omitempty"`
+}
+
+// ModulesSampling is the sampling keyed by module rule as ModulesLevel.
+type ModulesSampling map[string]Sampling
+
+// Lookup return the sampling of the most specific rule which applies to module.
+func (t ModulesSampling) Lookup(module string) (Sampling, bool) {
+	return lookupRule(t, module, func(a, b Sampling) bool { return false })
+}
+
+func (t ModulesSampling) normalize() ModulesSampling {
+	m := make(ModulesSampling, len(t))
+	for k, sampling := range t {
+		m[strings.ToLower(k)] = sampling
 	}
 
 	return m
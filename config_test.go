@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/kiraxie/logzap"
 	"github.com/mitchellh/mapstructure"
@@ -97,3 +98,64 @@ var decoderConfigOption = func(hookFuncs ...mapstructure.DecodeHookFunc) func(cf
 		}
 	}
 }(logzap.MapStructureLevelDecodeHook...)
+
+func TestModulesLevelLookup(t *testing.T) {
+	t.Parallel()
+	modules := logzap.ModulesLevel{
+		"parent":       zapcore.ErrorLevel,
+		"parent.child": zapcore.WarnLevel,
+		"parent.*":     zapcore.InfoLevel,
+		"db.*":         zapcore.DebugLevel,
+		"db.pool*":     zapcore.WarnLevel,
+		"*.metrics":    zapcore.ErrorLevel,
+	}
+	for name, expected := range map[string]zapcore.Level{
+		"parent":                  zapcore.ErrorLevel,
+		"parent.child":            zapcore.WarnLevel,
+		"parent.child.grandchild": zapcore.WarnLevel,
+		"parent.other":            zapcore.InfoLevel,
+		"Parent.Other.Grandchild": zapcore.InfoLevel,
+		"db.conn":                 zapcore.DebugLevel,
+		"db.pool":                 zapcore.WarnLevel,
+		"db.pool.idle":            zapcore.WarnLevel,
+		"http.metrics":            zapcore.ErrorLevel,
+		"http.metrics.exporter":   zapcore.ErrorLevel,
+	} {
+		lv, ok := modules.Lookup(name)
+		require.True(t, ok, name)
+		require.Equal(t, expected, lv, name)
+	}
+	_, ok := modules.Lookup("db")
+	require.False(t, ok)
+	_, ok = modules.Lookup("parentx")
+	require.False(t, ok)
+
+	require.ErrorIs(t, logzap.Config{Modules: logzap.ModulesLevel{"db.[": zapcore.InfoLevel}}.Validate(), logzap.ErrInvalidModuleLevel)
+}
+
+func TestDecodeSampling(t *testing.T) {
+	t.Parallel()
+	c, err := logzap.DecodeConfig([]byte(`
+level: info
+sampling:
+  db:
+    initial: 10
+    thereafter: 100
+    tick: 5s
+`))
+	require.NoError(t, err)
+	require.Equal(t, logzap.ModulesSampling{
+		"db": {Initial: 10, Thereafter: 100, Tick: 5 * time.Second},
+	}, c.Sampling)
+	require.NoError(t, c.Validate())
+	sampling, ok := c.Sampling.Lookup("db.query")
+	require.True(t, ok)
+	require.Equal(t, 10, sampling.Initial)
+
+	c.Sampling = logzap.ModulesSampling{"db": {Initial: -1}}
+	require.ErrorIs(t, c.Validate(), logzap.ErrInvalidSampling)
+	c.Sampling = logzap.ModulesSampling{"db": {}}
+	require.ErrorIs(t, c.Validate(), logzap.ErrInvalidSampling)
+	c.Sampling = logzap.ModulesSampling{"db": {Thereafter: 10}}
+	require.NoError(t, c.Validate())
+}
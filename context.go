@@ -0,0 +1,163 @@
+package logzap
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// ContextExtractor return the fields carried by ctx, such as the request ID,
+// which are attached to the entries logged with ctx.
+type ContextExtractor func(ctx context.Context) []zap.Field
+
+type namedExtractor struct {
+	name    string
+	extract ContextExtractor
+}
+
+var (
+	_extractorMu      sync.Mutex
+	_contextExtractor = map[string]ContextExtractor{
+		"otel": OpenTelemetryFields,
+	}
+	// _contextExtractors is the sorted snapshot of _contextExtractor for the
+	// lock-free lookups of logging
+	_contextExtractors atomic.Pointer[[]namedExtractor]
+)
+
+func init() {
+	storeContextExtractors()
+}
+
+// RegisterContextExtractor registers an extractor of the fields in context,
+// which is applied by the *Context methods of Logger in the order of name.
+// An existing extractor with the same name is replaced.
+func RegisterContextExtractor(name string, extractor ContextExtractor) {
+	_extractorMu.Lock()
+	defer _extractorMu.Unlock()
+	_contextExtractor[strings.ToLower(name)] = extractor
+	storeContextExtractors()
+}
+
+// UnregisterContextExtractor removes the extractor of the given name, including
+// the built-in "otel" one.
+func UnregisterContextExtractor(name string) {
+	_extractorMu.Lock()
+	defer _extractorMu.Unlock()
+	delete(_contextExtractor, strings.ToLower(name))
+	storeContextExtractors()
+}
+
+// storeContextExtractors must be called with _extractorMu held.
+func storeContextExtractors() {
+	extractors := make([]namedExtractor, 0, len(_contextExtractor))
+	for name, extract := range _contextExtractor {
+		extractors = append(extractors, namedExtractor{name: name, extract: extract})
+	}
+	sort.Slice(extractors, func(i, j int) bool { return extractors[i].name < extractors[j].name })
+	_contextExtractors.Store(&extractors)
+}
+
+// ContextFields return the fields of ctx added by WithFields followed by the
+// fields extracted by all registered extractors, deduplicated by key.
+func ContextFields(ctx context.Context) []zap.Field {
+	if ctx == nil {
+		return nil
+	}
+	fields := Fields(ctx)
+	for _, e := range *_contextExtractors.Load() {
+		fields = mergeFields(fields, e.extract(ctx))
+	}
+
+	return fields
+}
+
+type fieldsKey struct{}
+
+type loggerKey struct{}
+
+// WithFields return a copy of ctx carrying the fields, which are included by
+// the *Context methods of Logger. The fields are merged with the ones already
+// in ctx, a field replaces the earlier one with the same key in place.
+func WithFields(ctx context.Context, fields ...zap.Field) context.Context {
+	if len(fields) == 0 {
+		return ctx
+	}
+
+	return context.WithValue(ctx, fieldsKey{}, mergeFields(Fields(ctx), fields))
+}
+
+// Fields return the fields added to ctx by WithFields.
+func Fields(ctx context.Context) []zap.Field {
+	fields, _ := ctx.Value(fieldsKey{}).([]zap.Field)
+
+	return fields
+}
+
+// NewContext return a copy of ctx carrying the logger, which is returned by
+// FromContext.
+func NewContext(ctx context.Context, log Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, log)
+}
+
+// FromContext return the logger carried by ctx if module is empty, otherwise
+// the logger of module with the request-scoped fields of the carried logger.
+// The logger of module from the global instance is returned if ctx carries no
+// logger.
+func FromContext(ctx context.Context, module string) Logger {
+	log, ok := ctx.Value(loggerKey{}).(Logger)
+	if !ok {
+		return Get(module)
+	}
+	if module == "" {
+		return log
+	}
+	if l, ok := log.(*logger); ok {
+		return l.sibling(module)
+	}
+
+	return Get(module)
+}
+
+// mergeFields return the fields of base followed by the fields of add, the
+// field of add replaces the one in base with the same key in place. base is
+// never modified.
+func mergeFields(base, add []zap.Field) []zap.Field {
+	if len(add) == 0 {
+		return base
+	}
+	merged := make([]zap.Field, len(base), len(base)+len(add))
+	copy(merged, base)
+next:
+	for _, f := range add {
+		for i := range merged {
+			if merged[i].Key == f.Key {
+				merged[i] = f
+				continue next
+			}
+		}
+		merged = append(merged, f)
+	}
+
+	return merged
+}
+
+// OpenTelemetryFields return trace_id, span_id and trace_flags of the span in
+// ctx, or nothing if ctx carries no valid span.
+func OpenTelemetryFields(ctx context.Context) []zap.Field {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+
+	return []zap.Field{
+		zap.String("trace_id", sc.TraceID().String()),
+		zap.String("span_id", sc.SpanID().String()),
+		zap.String("trace_flags", sc.TraceFlags().String()),
+	}
+}
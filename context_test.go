@@ -0,0 +1,99 @@
+package logzap_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/kiraxie/logzap"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type requestIDKey struct{}
+
+func TestContextFields(t *testing.T) {
+	t.Parallel()
+	logzap.RegisterContextExtractor("test-request-id", func(ctx context.Context) []zap.Field {
+		if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+			return []zap.Field{zap.String("request_id", id)}
+		}
+
+		return nil
+	})
+	defer logzap.UnregisterContextExtractor("test-request-id")
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := logzap.Nop()
+	logger.Use(core)
+	logger.SetLevel("", zapcore.DebugLevel)
+	log := logger.Get("ctx")
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+	ctx = context.WithValue(ctx, requestIDKey{}, "req-1")
+
+	log.InfoContext(ctx, "info", zap.Int("n", 1))
+	log.DebugContext(context.Background(), "plain")
+	require.Error(t, log.TraceContext(ctx, errors.New("failed")))
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 3)
+	require.Equal(t, map[string]interface{}{
+		"n":           int64(1),
+		"trace_id":    "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":     "00f067aa0ba902b7",
+		"trace_flags": "01",
+		"request_id":  "req-1",
+	}, entries[0].ContextMap())
+	require.Equal(t, "context_test.go", filepath.Base(entries[0].Caller.File))
+	require.Empty(t, entries[1].Context)
+	require.Equal(t, "failed", entries[2].Message)
+	require.Equal(t, "req-1", entries[2].ContextMap()["request_id"])
+	require.Equal(t, "context_test.go", filepath.Base(entries[2].Caller.File))
+}
+
+func TestWithFields(t *testing.T) {
+	t.Parallel()
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := logzap.Nop()
+	logger.Use(core)
+
+	ctx := logzap.WithFields(context.Background(), zap.String("request_id", "req-1"), zap.String("user", "a"))
+	child := logzap.WithFields(ctx, zap.String("tenant", "t1"), zap.String("user", "b"))
+	require.Equal(t, []zap.Field{zap.String("request_id", "req-1"), zap.String("user", "a")}, logzap.Fields(ctx))
+	require.Equal(t, []zap.Field{
+		zap.String("request_id", "req-1"),
+		zap.String("user", "b"),
+		zap.String("tenant", "t1"),
+	}, logzap.Fields(child))
+
+	child = logzap.NewContext(child, logger.Get("http").With(zap.String("conn", "c1")))
+	logzap.FromContext(child, "").InfoContext(child, "request", zap.String("tenant", "t2"))
+	logzap.FromContext(child, "db").ErrorContext(child, "query")
+	logzap.FromContext(child, "db").Info("plain")
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 3)
+	require.Equal(t, "http", entries[0].LoggerName)
+	require.Equal(t, []zap.Field{
+		zap.String("conn", "c1"),
+		zap.String("request_id", "req-1"),
+		zap.String("user", "b"),
+		zap.String("tenant", "t2"),
+	}, entries[0].Context)
+	require.Equal(t, "db", entries[1].LoggerName)
+	require.Equal(t, "b", entries[1].ContextMap()["user"])
+	require.Equal(t, "c1", entries[1].ContextMap()["conn"])
+	require.Equal(t, "db", entries[2].LoggerName)
+	require.Equal(t, []zap.Field{zap.String("conn", "c1")}, entries[2].Context)
+}
@@ -3,6 +3,7 @@ package buffer
 import (
 	"bytes"
 	"context"
+	"net/url"
 
 	"github.com/kiraxie/logzap/filter"
 	"github.com/prometheus/client_golang/prometheus"
@@ -41,10 +42,15 @@ func (t *Buffer) Write(ent zapcore.Entry, _ []zapcore.Field) (err error) {
 	return
 }
 
+// buffer://name, an empty name accepts entries of all loggers
 func New(
 	_ context.Context,
 	_ prometheus.Registerer,
-	url string,
+	rawURL string,
 ) (zapcore.Core, error) {
-	return &Buffer{Name: url}, nil
+	if u, err := url.Parse(rawURL); err == nil && u.Scheme == "buffer" {
+		return &Buffer{Name: u.Host}, nil
+	}
+
+	return &Buffer{Name: rawURL}, nil
 }
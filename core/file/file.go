@@ -0,0 +1,204 @@
+// Package file implements a core which writes to a local file with size and
+// time based rotation, retention and compression of the rotated backups.
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kiraxie/logzap/filter"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var ErrInvalidOption = errors.New("invalid file option")
+
+// Core is a zapcore.Core which writes to a Writer.
+type Core struct {
+	zapcore.Core
+	w *Writer
+}
+
+// file:///var/log/app.log?maxsize=100MB&maxage=7d&maxbackups=10&compress=gzip&encoder=json
+//
+// The path may be a template of {module} and {date}, e.g.
+// file:///var/log/{module}/{date}.log?maxopen=64, the entries are routed to
+// the files by their logger name and day and each file rotates independently.
+func New(
+	ctx context.Context,
+	_ prometheus.Registerer,
+	rawURL string,
+) (zapcore.Core, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	path := u.Host + u.Path
+	if path == "" {
+		return nil, fmt.Errorf("%w: empty path", ErrInvalidOption)
+	}
+	opts, err := ParseOptions(u.Query())
+	if err != nil {
+		return nil, err
+	}
+	var encoder zapcore.Encoder
+	switch u.Query().Get("encoder") {
+	case "console":
+		encoder = zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig())
+	default:
+		encoder = zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	}
+	if u.Query().Get("filter") == "true" {
+		encoder = &filter.FilterEncoder{Encoder: encoder}
+	}
+	if isTemplate(path) {
+		maxOpen := DefaultMaxOpen
+		if v := u.Query().Get("maxopen"); v != "" {
+			if maxOpen, err = strconv.Atoi(v); err != nil || maxOpen <= 0 {
+				return nil, fmt.Errorf("%w: maxopen %q", ErrInvalidOption, v)
+			}
+		}
+		r := newRouter(path, opts, maxOpen)
+		go reopenOnHangup(ctx, r.done, r.Reopen, path)
+
+		return &templateCore{LevelEnabler: zapcore.DebugLevel, enc: encoder, router: r}, nil
+	}
+	w, err := NewWriter(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	go reopenOnHangup(ctx, w.done, w.Reopen, path)
+
+	return &Core{Core: zapcore.NewCore(encoder, w, zapcore.DebugLevel), w: w}, nil
+}
+
+// ParseOptions parses the query parameters maxsize, maxage, maxbackups,
+// interval and compress.
+func ParseOptions(query url.Values) (opts Options, err error) {
+	if v := query.Get("maxsize"); v != "" {
+		if opts.MaxSize, err = parseSize(v); err != nil {
+			return opts, fmt.Errorf("%w: maxsize %q", ErrInvalidOption, v)
+		}
+	}
+	if v := query.Get("maxage"); v != "" {
+		if opts.MaxAge, err = parseDuration(v); err != nil {
+			return opts, fmt.Errorf("%w: maxage %q", ErrInvalidOption, v)
+		}
+	}
+	if v := query.Get("interval"); v != "" {
+		if opts.Interval, err = parseDuration(v); err != nil {
+			return opts, fmt.Errorf("%w: interval %q", ErrInvalidOption, v)
+		}
+	}
+	if v := query.Get("maxbackups"); v != "" {
+		if opts.MaxBackups, err = strconv.Atoi(v); err != nil || opts.MaxBackups < 0 {
+			return opts, fmt.Errorf("%w: maxbackups %q", ErrInvalidOption, v)
+		}
+	}
+	opts.Compress = query.Get("compress")
+	if _, ok := compressExt[opts.Compress]; opts.Compress != "" && !ok {
+		return opts, fmt.Errorf("%w: compress %q", ErrInvalidOption, opts.Compress)
+	}
+
+	return opts, nil
+}
+
+func (t *Core) With(fields []zapcore.Field) zapcore.Core {
+	return &Core{Core: t.Core.With(fields), w: t.w}
+}
+
+// Writer return the file written by the core.
+func (t *Core) Writer() *Writer {
+	return t.w
+}
+
+// Flush commits the file to the disk.
+func (t *Core) Flush(_ context.Context) error {
+	return t.w.Sync()
+}
+
+// Close closes the file, the core is unusable after that.
+func (t *Core) Close(ctx context.Context) error {
+	return t.w.Close(ctx)
+}
+
+// reopenOnHangup reopens the files on SIGHUP, as logrotate expects, until
+// done is closed or ctx is done.
+func reopenOnHangup(ctx context.Context, done <-chan struct{}, reopen func() error, path string) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	defer signal.Stop(sig)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			return
+		case <-sig:
+			if err := reopen(); err != nil && !errors.Is(err, os.ErrClosed) {
+				fmt.Fprintf(os.Stderr, "logzap file %s: %v\n", path, err)
+			}
+		}
+	}
+}
+
+var sizeUnits = []struct {
+	suffix string
+	scale  int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+	{"B", 1},
+}
+
+// parseSize parses a size in bytes with an optional suffix K, KB, M, MB, G or
+// GB in the powers of 1024.
+func parseSize(s string) (int64, error) {
+	upper, scale := strings.ToUpper(strings.TrimSpace(s)), int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(upper, u.suffix) {
+			upper, scale = strings.TrimSpace(strings.TrimSuffix(upper, u.suffix)), u.scale
+			break
+		}
+	}
+	n, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, strconv.ErrRange
+	}
+
+	return n * scale, nil
+}
+
+// parseDuration parses a time.Duration which also accepts the days "7d".
+func parseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, strconv.ErrSyntax
+		}
+
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err == nil && d < 0 {
+		return 0, strconv.ErrRange
+	}
+
+	return d, err
+}
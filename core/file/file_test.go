@@ -0,0 +1,221 @@
+package file_test
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kiraxie/logzap/core/file"
+	"github.com/klauspost/compress/zstd"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func backups(t *testing.T, dir, pattern string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, pattern))
+	require.NoError(t, err)
+
+	return matches
+}
+
+func TestFile(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	core, err := file.New(context.Background(), prometheus.NewRegistry(), "file://"+path+"?encoder=json")
+	require.NoError(t, err)
+	logger := zap.New(core).Named("foo")
+	logger.Info("hello", zap.String("key", "value"))
+	require.NoError(t, logger.Sync())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(content), `"logger":"foo"`)
+	require.Contains(t, string(content), `"msg":"hello"`)
+	require.Contains(t, string(content), `"key":"value"`)
+
+	require.NoError(t, core.(*file.Core).Flush(context.Background()))
+	require.NoError(t, core.(*file.Core).Close(context.Background()))
+	require.ErrorIs(t, core.Write(zapcore.Entry{Message: "after close"}, nil), os.ErrClosed)
+	require.NoError(t, core.(*file.Core).Close(context.Background()))
+}
+
+func TestParseOptions(t *testing.T) {
+	t.Parallel()
+	query, err := url.ParseQuery("maxsize=100MB&maxage=7d&maxbackups=10&compress=zstd&interval=1h")
+	require.NoError(t, err)
+	opts, err := file.ParseOptions(query)
+	require.NoError(t, err)
+	require.Equal(t, file.Options{
+		MaxSize:    100 << 20,
+		MaxAge:     7 * 24 * time.Hour,
+		MaxBackups: 10,
+		Compress:   "zstd",
+		Interval:   time.Hour,
+	}, opts)
+
+	for _, raw := range []string{"maxsize=big", "maxage=-1d", "maxbackups=-1", "compress=lz4", "interval=soon"} {
+		query, err := url.ParseQuery(raw)
+		require.NoError(t, err)
+		_, err = file.ParseOptions(query)
+		require.ErrorIs(t, err, file.ErrInvalidOption, raw)
+	}
+}
+
+func TestWriterRotate(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	w, err := file.NewWriter(path, file.Options{MaxSize: 10, MaxBackups: 2})
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		_, err := w.Write([]byte("0123456789"))
+		require.NoError(t, err)
+	}
+	require.Eventually(t, func() bool {
+		return len(backups(t, dir, "app-*.log")) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, w.Close(context.Background()))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "0123456789", string(content))
+	_, err = w.Write([]byte("closed"))
+	require.ErrorIs(t, err, os.ErrClosed)
+}
+
+func TestWriterCompress(t *testing.T) {
+	t.Parallel()
+	for method, open := range map[string]func(io.Reader) (io.Reader, error){
+		"gzip": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"zstd": func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) },
+	} {
+		method, open := method, open
+		t.Run(method, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			// the leftover of a crashed compression is removed
+			leftover := filepath.Join(dir, "app-2000-01-01T00-00-00.000.log.gz.tmp")
+			require.NoError(t, os.WriteFile(leftover, []byte("partial"), 0o644))
+			w, err := file.NewWriter(filepath.Join(dir, "app.log"), file.Options{Compress: method})
+			require.NoError(t, err)
+			_, err = w.Write([]byte("rotated"))
+			require.NoError(t, err)
+			require.NoError(t, w.Rotate())
+
+			var compressed []string
+			require.Eventually(t, func() bool {
+				compressed = backups(t, dir, "app-*.log.*")
+
+				return len(compressed) == 1 && !strings.HasSuffix(compressed[0], ".tmp")
+			}, 5*time.Second, 10*time.Millisecond)
+			require.NoError(t, w.Close(context.Background()))
+			require.Empty(t, backups(t, dir, "app-*.log"))
+
+			f, err := os.Open(compressed[0])
+			require.NoError(t, err)
+			defer f.Close()
+			r, err := open(f)
+			require.NoError(t, err)
+			content, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.Equal(t, "rotated", string(content))
+		})
+	}
+}
+
+func TestWriterMaxAge(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	expired := filepath.Join(dir, "app-2000-01-01T00-00-00.000.log")
+	require.NoError(t, os.WriteFile(expired, []byte("old"), 0o644))
+	leftover := filepath.Join(dir, "app-2000-01-01T00-00-00.000.log.gz.tmp")
+	require.NoError(t, os.WriteFile(leftover, []byte("partial"), 0o644))
+	// the files of "app-replica" share the prefix but are not the backups of app
+	others := []string{
+		filepath.Join(dir, "other-2000-01-01T00-00-00.000.log"),
+		filepath.Join(dir, "app-replica-2000-01-01T00-00-00.000.log"),
+		filepath.Join(dir, "app-replica-2000-01-01T00-00-00.000.log.gz.tmp"),
+	}
+	for _, other := range others {
+		require.NoError(t, os.WriteFile(other, []byte("old"), 0o644))
+	}
+	w, err := file.NewWriter(filepath.Join(dir, "app.log"), file.Options{MaxAge: 24 * time.Hour})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(expired)
+		_, e := os.Stat(leftover)
+
+		return os.IsNotExist(err) && os.IsNotExist(e)
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, w.Close(context.Background()))
+	for _, other := range others {
+		require.FileExists(t, other)
+	}
+}
+
+func TestWriterReopen(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	w, err := file.NewWriter(path, file.Options{})
+	require.NoError(t, err)
+	defer w.Close(context.Background())
+	_, err = w.Write([]byte("before"))
+	require.NoError(t, err)
+	// logrotate moves the file and signals the process
+	require.NoError(t, os.Rename(path, path+".1"))
+	require.NoError(t, w.Reopen())
+	_, err = w.Write([]byte("after"))
+	require.NoError(t, err)
+	require.NoError(t, w.Sync())
+
+	moved, err := os.ReadFile(path + ".1")
+	require.NoError(t, err)
+	require.Equal(t, "before", string(moved))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "after", string(content))
+}
+
+func TestWriterRecover(t *testing.T) {
+	t.Parallel()
+	dir := filepath.Join(t.TempDir(), "logs")
+	path := filepath.Join(dir, "app.log")
+	w, err := file.NewWriter(path, file.Options{})
+	require.NoError(t, err)
+
+	// the file can't be opened again while the directory is missing
+	require.NoError(t, os.RemoveAll(dir))
+	require.Error(t, w.Rotate())
+	_, err = w.Write([]byte("lost\n"))
+	require.Error(t, err)
+	require.NotErrorIs(t, err, os.ErrClosed)
+
+	// and it is opened by the next write once the cause is cleared
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	_, err = w.Write([]byte("recovered\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Reopen())
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "recovered\n", string(content))
+
+	// Close stops the writer whatever the state of file
+	require.NoError(t, os.RemoveAll(dir))
+	require.Error(t, w.Reopen())
+	require.NoError(t, w.Close(context.Background()))
+	_, err = w.Write([]byte("closed\n"))
+	require.ErrorIs(t, err, os.ErrClosed)
+	require.ErrorIs(t, w.Reopen(), os.ErrClosed)
+	require.NoError(t, w.Close(context.Background()))
+}
@@ -0,0 +1,335 @@
+package file
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// DefaultMaxOpen is the number of files kept open by a path template.
+	DefaultMaxOpen = 64
+	// RootModule is the {module} of the entries written by the unnamed logger.
+	RootModule = "root"
+)
+
+const (
+	placeholderModule = "{module}"
+	placeholderDate   = "{date}"
+	dateFormat        = "2006-01-02"
+	// dateGlob matches the dates of dateFormat
+	dateGlob = "[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]"
+)
+
+var (
+	templateReplacer = strings.NewReplacer("/", "_", `\`, "_")
+	globReplacer     = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`)
+)
+
+// isTemplate reports whether the path has a placeholder.
+func isTemplate(path string) bool {
+	return strings.Contains(path, placeholderModule) || strings.Contains(path, placeholderDate)
+}
+
+// segment is a literal or a placeholder of path template.
+type segment struct {
+	literal     string
+	placeholder string
+}
+
+// parseTemplate splits the path template into segments.
+func parseTemplate(template string) (segments []segment) {
+	for template != "" {
+		i, placeholder := -1, ""
+		for _, p := range []string{placeholderModule, placeholderDate} {
+			if j := strings.Index(template, p); j >= 0 && (i < 0 || j < i) {
+				i, placeholder = j, p
+			}
+		}
+		if i < 0 {
+			return append(segments, segment{literal: template})
+		}
+		if i > 0 {
+			segments = append(segments, segment{literal: template[:i]})
+		}
+		segments = append(segments, segment{placeholder: placeholder})
+		template = template[i+len(placeholder):]
+	}
+
+	return
+}
+
+// router owns the files of a path template, at most maxOpen of them are open
+// and the least recently written one is closed to open another.
+type router struct {
+	mu       sync.Mutex
+	template []segment
+	opts     Options
+	maxOpen  int
+	files    map[string]*list.Element
+	lru      *list.List
+	closed   bool
+	done     chan struct{}
+	// closing is the evicted writers closed in the background
+	closing sync.WaitGroup
+	// millMu serializes the maintenance of backups of all writers
+	millMu sync.Mutex
+}
+
+type routeFile struct {
+	path string
+	w    *Writer
+}
+
+func newRouter(template string, opts Options, maxOpen int) *router {
+	if maxOpen <= 0 {
+		maxOpen = DefaultMaxOpen
+	}
+
+	return &router{
+		template: parseTemplate(template),
+		opts:     opts,
+		maxOpen:  maxOpen,
+		files:    map[string]*list.Element{},
+		lru:      list.New(),
+		done:     make(chan struct{}),
+	}
+}
+
+// module return the {module} of entry, which is the logger name whose path
+// separators are replaced.
+func module(ent zapcore.Entry) string {
+	module := ent.LoggerName
+	if module == "" {
+		return RootModule
+	}
+	module = templateReplacer.Replace(module)
+	if module == "." || module == ".." {
+		module = "_"
+	}
+
+	return module
+}
+
+// path return the path of module at the date of entry.
+func (t *router) path(module string, ent zapcore.Entry) string {
+	var b strings.Builder
+	for _, s := range t.template {
+		switch s.placeholder {
+		case placeholderModule:
+			b.WriteString(module)
+		case placeholderDate:
+			b.WriteString(ent.Time.Format(dateFormat))
+		default:
+			b.WriteString(s.literal)
+		}
+	}
+
+	return filepath.Clean(b.String())
+}
+
+// dates return the glob of the files of module at all dates, or empty if the
+// template has no date.
+func (t *router) dates(module string) string {
+	var b strings.Builder
+	dated := false
+	for _, s := range t.template {
+		switch s.placeholder {
+		case placeholderModule:
+			b.WriteString(globReplacer.Replace(module))
+		case placeholderDate:
+			b.WriteString(dateGlob)
+			dated = true
+		default:
+			b.WriteString(globReplacer.Replace(s.literal))
+		}
+	}
+	if !dated {
+		return ""
+	}
+
+	return b.String()
+}
+
+func (t *router) Write(ent zapcore.Entry, p []byte) error {
+	module := module(ent)
+	path := t.path(module, ent)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return os.ErrClosed
+	}
+	w, err := t.writer(path, module)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(p)
+
+	return err
+}
+
+// writer return the writer of path, it opens the file and evicts the least
+// recently used ones when needed, which are closed in the background. It must
+// be called with mu held.
+func (t *router) writer(path, module string) (*Writer, error) {
+	if e, ok := t.files[path]; ok {
+		t.lru.MoveToFront(e)
+
+		return e.Value.(*routeFile).w, nil
+	}
+	w, err := newWriter(path, t.opts, &fileGroup{mu: &t.millMu, dates: t.dates(module), isOpen: t.isOpen})
+	if err != nil {
+		return nil, err
+	}
+	t.files[path] = t.lru.PushFront(&routeFile{path: path, w: w})
+	for t.lru.Len() > t.maxOpen {
+		f := t.lru.Remove(t.lru.Back()).(*routeFile)
+		delete(t.files, f.path)
+		t.closing.Add(1)
+		go func() {
+			defer t.closing.Done()
+			if err := f.w.Close(context.Background()); err != nil {
+				fmt.Fprintf(os.Stderr, "logzap file %s: %v\n", f.path, err)
+			}
+		}()
+	}
+
+	return w, nil
+}
+
+// isOpen reports whether the file of path is open.
+func (t *router) isOpen(path string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.files[path]
+
+	return ok
+}
+
+// open return the writers currently open.
+func (t *router) open() []*Writer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	writers := make([]*Writer, 0, t.lru.Len())
+	for e := t.lru.Front(); e != nil; e = e.Next() {
+		writers = append(writers, e.Value.(*routeFile).w)
+	}
+
+	return writers
+}
+
+func (t *router) Sync() (err error) {
+	for _, w := range t.open() {
+		err = multierr.Append(err, w.Sync())
+	}
+
+	return
+}
+
+func (t *router) Reopen() (err error) {
+	for _, w := range t.open() {
+		// the writer evicted meanwhile is reopened by the next entry
+		if e := w.Reopen(); !errors.Is(e, os.ErrClosed) {
+			err = multierr.Append(err, e)
+		}
+	}
+
+	return
+}
+
+func (t *router) Close(ctx context.Context) (err error) {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+
+		return nil
+	}
+	writers := make([]*Writer, 0, t.lru.Len())
+	for e := t.lru.Front(); e != nil; e = e.Next() {
+		writers = append(writers, e.Value.(*routeFile).w)
+	}
+	t.closed = true
+	t.files = map[string]*list.Element{}
+	t.lru.Init()
+	close(t.done)
+	t.mu.Unlock()
+
+	for _, w := range writers {
+		err = multierr.Append(err, w.Close(ctx))
+	}
+	// the evicted writers finish closing too
+	closed := make(chan struct{})
+	go func() {
+		t.closing.Wait()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-ctx.Done():
+		err = multierr.Append(err, ctx.Err())
+	}
+
+	return
+}
+
+// templateCore is a zapcore.Core which writes to the files of a path template.
+type templateCore struct {
+	zapcore.LevelEnabler
+	enc    zapcore.Encoder
+	router *router
+}
+
+func (t *templateCore) With(fields []zapcore.Field) zapcore.Core {
+	enc := t.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	return &templateCore{LevelEnabler: t.LevelEnabler, enc: enc, router: t.router}
+}
+
+func (t *templateCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if t.Enabled(ent.Level) {
+		return ce.AddCore(ent, t)
+	}
+
+	return ce
+}
+
+func (t *templateCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := t.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	err = t.router.Write(ent, buf.Bytes())
+	buf.Free()
+	if err != nil {
+		return err
+	}
+	if ent.Level > zapcore.ErrorLevel {
+		// the process may exit after the entry, as zapcore.ioCore does
+		return t.router.Sync()
+	}
+
+	return nil
+}
+
+func (t *templateCore) Sync() error {
+	return t.router.Sync()
+}
+
+func (t *templateCore) Flush(_ context.Context) error {
+	return t.router.Sync()
+}
+
+func (t *templateCore) Close(ctx context.Context) error {
+	return t.router.Close(ctx)
+}
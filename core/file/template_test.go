@@ -0,0 +1,98 @@
+package file_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kiraxie/logzap/core/file"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestTemplate(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	core, err := file.New(
+		context.Background(),
+		prometheus.NewRegistry(),
+		"file://"+dir+"/{module}/{date}.log?maxopen=2",
+	)
+	require.NoError(t, err)
+	logger := zap.New(core)
+	logger.Info("root")
+	logger.Named("audit").Info("audit")
+	logger.Named("billing").With(zap.String("tenant", "a")).Info("billing")
+	// audit was evicted by billing and is opened again
+	logger.Named("audit").Info("again")
+	require.NoError(t, logger.Sync())
+
+	date := time.Now().Format("2006-01-02")
+	read := func(module string) string {
+		content, err := os.ReadFile(filepath.Join(dir, module, date+".log"))
+		require.NoError(t, err)
+
+		return string(content)
+	}
+	require.Contains(t, read(file.RootModule), `"msg":"root"`)
+	require.Contains(t, read("audit"), `"msg":"audit"`)
+	require.Contains(t, read("audit"), `"msg":"again"`)
+	require.NotContains(t, read("audit"), `"msg":"billing"`)
+	require.Contains(t, read("billing"), `"tenant":"a"`)
+
+	closer := core.(interface{ Close(context.Context) error })
+	require.NoError(t, closer.Close(context.Background()))
+	require.ErrorIs(t, core.Write(zapcore.Entry{LoggerName: "audit"}, nil), os.ErrClosed)
+	require.NoError(t, closer.Close(context.Background()))
+}
+
+func TestTemplateDate(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	core, err := file.New(context.Background(), prometheus.NewRegistry(), "file://"+dir+"/{module}-{date}.log")
+	require.NoError(t, err)
+	defer core.(interface{ Close(context.Context) error }).Close(context.Background())
+	day := time.Date(2000, 1, 2, 12, 0, 0, 0, time.Local)
+	require.NoError(t, core.Write(zapcore.Entry{LoggerName: "a/b", Time: day}, nil))
+	require.NoError(t, core.Write(zapcore.Entry{LoggerName: "a/b", Time: day.Add(24 * time.Hour)}, nil))
+	require.NoError(t, core.Sync())
+	require.FileExists(t, filepath.Join(dir, "a_b-2000-01-02.log"))
+	require.FileExists(t, filepath.Join(dir, "a_b-2000-01-03.log"))
+
+	_, err = file.New(context.Background(), prometheus.NewRegistry(), "file://"+dir+"/{module}.log?maxopen=0")
+	require.ErrorIs(t, err, file.ErrInvalidOption)
+}
+
+func TestTemplateRetention(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "audit"), 0o755))
+	// the files of earlier dates are the backups of the module
+	now := time.Now()
+	for i, date := range []string{"2000-01-01", "2000-01-02"} {
+		path := filepath.Join(dir, "audit", date+".log")
+		require.NoError(t, os.WriteFile(path, []byte(date), 0o644))
+		modified := now.Add(time.Duration(i-3) * 24 * time.Hour)
+		require.NoError(t, os.Chtimes(path, modified, modified))
+	}
+	core, err := file.New(context.Background(), prometheus.NewRegistry(),
+		"file://"+dir+"/{module}/{date}.log?maxbackups=1&compress=gzip")
+	require.NoError(t, err)
+	defer core.(interface{ Close(context.Context) error }).Close(context.Background())
+
+	// the file of an earlier date still written is kept as is
+	require.NoError(t, core.Write(zapcore.Entry{LoggerName: "audit", Time: time.Date(2000, 1, 3, 0, 0, 0, 0, time.Local)}, nil))
+	require.NoError(t, core.Write(zapcore.Entry{LoggerName: "audit", Time: now}, nil))
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(filepath.Join(dir, "audit", "2000-01-01.log"))
+
+		return os.IsNotExist(err) && len(backups(t, filepath.Join(dir, "audit"), "2000-01-02.log.gz")) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoFileExists(t, filepath.Join(dir, "audit", "2000-01-02.log"))
+	require.FileExists(t, filepath.Join(dir, "audit", "2000-01-03.log"))
+	require.FileExists(t, filepath.Join(dir, "audit", now.Format("2006-01-02")+".log"))
+}
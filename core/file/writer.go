@@ -0,0 +1,492 @@
+package file
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	tmpSuffix        = ".tmp"
+)
+
+var compressExt = map[string]string{
+	"gzip": ".gz",
+	"zstd": ".zst",
+}
+
+// Options is the rotation and retention of Writer, the zero values disable
+// the corresponding feature.
+type Options struct {
+	// MaxSize rotates the file before it grows over MaxSize bytes.
+	MaxSize int64
+	// Interval rotates the file at every multiple of Interval since the zero
+	// time, e.g. 24h rotates at midnight UTC.
+	Interval time.Duration
+	// MaxAge removes the backups rotated before MaxAge ago.
+	MaxAge time.Duration
+	// MaxBackups keeps the newest MaxBackups backups only.
+	MaxBackups int
+	// Compress compresses the backups with "gzip" or "zstd".
+	Compress string
+}
+
+// Writer is a file which rotates itself.
+//
+// The file is renamed to a backup "name-<time>.ext" when it rotates, which is
+// atomic so a crash leaves either the file or the backup. The backups are
+// compressed into a temporary file which is renamed when complete, and the
+// temporary files left by a crash are removed by the next run.
+type Writer struct {
+	mu   sync.Mutex
+	path string
+	opts Options
+	// file is nil after a failed open, which is retried by the next write
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	closed   bool
+
+	mill chan struct{}
+	done chan struct{}
+	wg   sync.WaitGroup
+	// group is the files of the path template which shares the retention,
+	// nil for a plain path
+	group *fileGroup
+}
+
+// fileGroup is the files expanded from a path template for a module. The
+// backups of its writers are maintained one at a time, and the files of other
+// dates are retained as the backups of the writer.
+type fileGroup struct {
+	mu *sync.Mutex
+	// dates is the glob of the files of all dates, empty if the template has
+	// no date
+	dates string
+	// isOpen reports whether a file is written by another writer
+	isOpen func(path string) bool
+}
+
+// NewWriter opens or creates the file of path and starts the maintenance of
+// its backups.
+func NewWriter(path string, opts Options) (*Writer, error) {
+	return newWriter(path, opts, nil)
+}
+
+func newWriter(path string, opts Options, group *fileGroup) (*Writer, error) {
+	if _, ok := compressExt[opts.Compress]; opts.Compress != "" && !ok {
+		return nil, fmt.Errorf("%w: compress %q", ErrInvalidOption, opts.Compress)
+	}
+	t := &Writer{
+		path:  path,
+		opts:  opts,
+		mill:  make(chan struct{}, 1),
+		done:  make(chan struct{}),
+		group: group,
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := t.open(); err != nil {
+		return nil, err
+	}
+	t.wg.Add(1)
+	go t.runMill()
+	t.mill <- struct{}{}
+
+	return t, nil
+}
+
+func (t *Writer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return 0, os.ErrClosed
+	}
+	if t.file == nil {
+		if err := t.open(); err != nil {
+			return 0, err
+		}
+	}
+	if t.shouldRotate(int64(len(p)), time.Now()) {
+		if err := t.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := t.file.Write(p)
+	t.size += int64(n)
+
+	return n, err
+}
+
+// Sync commits the content of file to the disk.
+func (t *Writer) Sync() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.file == nil {
+		return nil
+	}
+
+	return t.file.Sync()
+}
+
+// Rotate rotates the file immediately.
+func (t *Writer) Rotate() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return os.ErrClosed
+	}
+
+	return t.rotate()
+}
+
+// Reopen closes and reopens the file of path, which is moved by an external
+// tool such as logrotate.
+func (t *Writer) Reopen() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return os.ErrClosed
+	}
+	if t.file != nil {
+		if err := t.closeFile(); err != nil {
+			return err
+		}
+	}
+
+	return t.open()
+}
+
+// Close closes the file and waits for the maintenance of backups until ctx is
+// done.
+func (t *Writer) Close(ctx context.Context) error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+
+		return nil
+	}
+	t.closed = true
+	var err error
+	if t.file != nil {
+		err = t.closeFile()
+	}
+	t.mu.Unlock()
+	close(t.done)
+
+	finished := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *Writer) shouldRotate(n int64, now time.Time) bool {
+	if t.opts.MaxSize > 0 && t.size > 0 && t.size+n > t.opts.MaxSize {
+		return true
+	}
+
+	return t.opts.Interval > 0 && !now.Truncate(t.opts.Interval).Equal(t.openedAt.Truncate(t.opts.Interval))
+}
+
+// open must be called with mu held.
+func (t *Writer) open() error {
+	f, err := os.OpenFile(t.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+
+		return err
+	}
+	t.file, t.size, t.openedAt = f, info.Size(), time.Now()
+	if info.Size() > 0 {
+		t.openedAt = info.ModTime()
+	}
+
+	return nil
+}
+
+// closeFile must be called with mu held.
+func (t *Writer) closeFile() error {
+	f := t.file
+	t.file = nil
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
+
+// rotate renames the file to a backup and opens a new one, it must be called
+// with mu held.
+func (t *Writer) rotate() error {
+	if t.file != nil {
+		if err := t.closeFile(); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(t.path, t.backupName(time.Now())); err != nil && !os.IsNotExist(err) {
+		// keep writing to the file which failed to rotate
+		_ = t.open()
+
+		return err
+	}
+	if err := t.open(); err != nil {
+		return err
+	}
+	select {
+	case t.mill <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// backupName return an unused name of backup rotated at now.
+func (t *Writer) backupName(now time.Time) string {
+	prefix, ext := nameParts(t.path)
+	for {
+		name := filepath.Join(filepath.Dir(t.path), prefix+now.UTC().Format(backupTimeFormat)+ext)
+		if _, err := os.Lstat(name); os.IsNotExist(err) {
+			return name
+		}
+		now = now.Add(time.Millisecond)
+	}
+}
+
+// nameParts return the prefix and extension of the backups of path.
+func nameParts(path string) (prefix, ext string) {
+	base := filepath.Base(path)
+	ext = filepath.Ext(base)
+
+	return strings.TrimSuffix(base, ext) + "-", ext
+}
+
+func (t *Writer) runMill() {
+	defer t.wg.Done()
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-t.mill:
+			if err := t.millOnce(); err != nil {
+				fmt.Fprintf(os.Stderr, "logzap file %s: %v\n", t.path, err)
+			}
+		}
+	}
+}
+
+type backup struct {
+	path       string
+	rotated    time.Time
+	compressed bool
+}
+
+// millOnce removes the temporary files left by a crash and the expired
+// backups, then compresses the others.
+func (t *Writer) millOnce() error {
+	if t.group != nil {
+		t.group.mu.Lock()
+		defer t.group.mu.Unlock()
+	}
+	backups, err := t.backups()
+	if err != nil {
+		return err
+	}
+	var remove, compress []backup
+	for i, b := range backups {
+		if (t.opts.MaxBackups > 0 && i >= t.opts.MaxBackups) ||
+			(t.opts.MaxAge > 0 && time.Since(b.rotated) > t.opts.MaxAge) {
+			remove = append(remove, b)
+		} else if t.opts.Compress != "" && !b.compressed {
+			compress = append(compress, b)
+		}
+	}
+	for _, b := range remove {
+		if e := os.Remove(b.path); e != nil && !os.IsNotExist(e) {
+			err = e
+		}
+	}
+	for _, b := range compress {
+		if e := compressFile(b.path, t.opts.Compress); e != nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// backups return the backups of file from the newest, including the files of
+// other dates in the group, and removes the temporary files.
+func (t *Writer) backups() ([]backup, error) {
+	backups, err := listBackups(t.path)
+	if err != nil {
+		return nil, err
+	}
+	if t.group != nil && t.group.dates != "" {
+		dated, err := t.datedFiles()
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, dated...)
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].rotated.After(backups[j].rotated) })
+
+	return backups, nil
+}
+
+// datedFiles return the files of other dates with their backups, the files
+// still written by another writer are kept but their backups are not.
+func (t *Writer) datedFiles() (backups []backup, err error) {
+	seen := map[string]bool{t.path: true}
+	for _, cext := range append([]string{""}, compressExt["gzip"], compressExt["zstd"]) {
+		matches, err := filepath.Glob(t.group.dates + cext)
+		if err != nil {
+			return nil, err
+		}
+		if cext != "" {
+			tmps, err := filepath.Glob(t.group.dates + cext + tmpSuffix)
+			if err != nil {
+				return nil, err
+			}
+			for _, tmp := range tmps {
+				_ = os.Remove(tmp)
+			}
+		}
+		for _, path := range matches {
+			base := strings.TrimSuffix(path, cext)
+			if !seen[base] {
+				seen[base] = true
+				rotated, err := listBackups(base)
+				if err != nil {
+					return nil, err
+				}
+				backups = append(backups, rotated...)
+			}
+			if cext == "" && t.group.isOpen(path) {
+				continue
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			backups = append(backups, backup{path: path, rotated: info.ModTime(), compressed: cext != ""})
+		}
+	}
+
+	return backups, nil
+}
+
+// listBackups return the backups of path, and removes the temporary files of
+// them.
+func listBackups(path string) ([]backup, error) {
+	dir := filepath.Dir(path)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	prefix, ext := nameParts(path)
+	var backups []backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp, tmp := strings.CutSuffix(strings.TrimPrefix(name, prefix), tmpSuffix)
+		compressed := false
+		for _, cext := range compressExt {
+			if strings.HasSuffix(stamp, ext+cext) {
+				stamp, compressed = strings.TrimSuffix(stamp, cext), true
+			}
+		}
+		if !strings.HasSuffix(stamp, ext) {
+			continue
+		}
+		// the name must be exactly a backup, so the backups of "db-replica"
+		// are not taken for the ones of "db"
+		rotated, err := time.Parse(backupTimeFormat, strings.TrimSuffix(stamp, ext))
+		if err != nil {
+			continue
+		}
+		if tmp {
+			_ = os.Remove(filepath.Join(dir, name))
+			continue
+		}
+		backups = append(backups, backup{path: filepath.Join(dir, name), rotated: rotated, compressed: compressed})
+	}
+
+	return backups, nil
+}
+
+// compressFile compresses the file into a temporary file, which is renamed to
+// the compressed backup before the file is removed.
+func compressFile(path, method string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst := path + compressExt[method]
+	tmp, err := os.OpenFile(dst+tmpSuffix, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+	var w io.WriteCloser
+	switch method {
+	case "zstd":
+		if w, err = zstd.NewWriter(tmp); err != nil {
+			return err
+		}
+	default:
+		w = gzip.NewWriter(tmp)
+	}
+	if _, err = io.Copy(w, src); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), dst); err != nil {
+		return err
+	}
+	// the age of backup without time in its name is the modification time
+	if info, e := src.Stat(); e == nil {
+		_ = os.Chtimes(dst, info.ModTime(), info.ModTime())
+	}
+
+	return os.Remove(path)
+}
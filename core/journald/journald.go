@@ -0,0 +1,275 @@
+// Package journald implements a core which sends the entries to
+// systemd-journald by its native protocol, the fields of entry are kept as
+// journal fields.
+package journald
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultSocket is the native socket of journald.
+const DefaultSocket = "/run/systemd/journal/socket"
+
+var ErrClosed = errors.New("journald closed")
+
+var bufferPool = buffer.NewPool()
+
+// reserved is the fields written by the core or interpreted by journald,
+// which the fields of entry can't override.
+var reserved = map[string]bool{
+	"MESSAGE": true, "MESSAGE_ID": true, "PRIORITY": true,
+	"CODE_FILE": true, "CODE_LINE": true, "CODE_FUNC": true,
+	"ERRNO": true, "INVOCATION_ID": true, "USER_INVOCATION_ID": true,
+	"SYSLOG_FACILITY": true, "SYSLOG_IDENTIFIER": true, "SYSLOG_PID": true,
+	"SYSLOG_TIMESTAMP": true, "SYSLOG_RAW": true, "DOCUMENTATION": true,
+	"TID": true, "UNIT": true, "USER_UNIT": true,
+	"LOGZAP_MODULE": true, "STACKTRACE": true,
+}
+
+// Priority return the syslog priority of level, the levels below DebugLevel
+// are debug too.
+func Priority(lv zapcore.Level) int {
+	switch {
+	case lv <= zapcore.DebugLevel:
+		return 7 // debug
+	case lv == zapcore.InfoLevel:
+		return 6 // info
+	case lv == zapcore.WarnLevel:
+		return 4 // warning
+	case lv == zapcore.ErrorLevel:
+		return 3 // err
+	case lv == zapcore.DPanicLevel:
+		return 2 // crit
+	case lv == zapcore.PanicLevel:
+		return 1 // alert
+	default:
+		return 0 // emerg
+	}
+}
+
+// FieldName return the journal field name of key, which is uppercase letters,
+// digits and underscores not starting with an underscore or digit. The names
+// reserved by the core and journald, such as MESSAGE, are prefixed with "F_".
+func FieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+	name = strings.TrimLeft(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') || reserved[name] {
+		name = "F_" + name
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+
+	return name
+}
+
+// Core is a zapcore.Core which sends the entries to journald.
+//
+// The module is SYSLOG_IDENTIFIER and LOGZAP_MODULE, the entries of the unnamed
+// logger are identified by the executable name or the parameter identifier.
+type Core struct {
+	zapcore.LevelEnabler
+	identifier string
+	fields     []zapcore.Field
+	conn       *conn
+}
+
+// journald://?identifier=myapp, journald:///path/to/socket
+func New(
+	_ context.Context,
+	_ prometheus.Registerer,
+	rawURL string,
+) (zapcore.Core, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	socket := u.Host + u.Path
+	if socket == "" {
+		socket = DefaultSocket
+	}
+	identifier := u.Query().Get("identifier")
+	if identifier == "" {
+		identifier = filepath.Base(os.Args[0])
+	}
+	c, err := newConn(socket)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Core{LevelEnabler: zapcore.DebugLevel, identifier: identifier, conn: c}, nil
+}
+
+func (t *Core) With(fields []zapcore.Field) zapcore.Core {
+	return &Core{
+		LevelEnabler: t.LevelEnabler,
+		identifier:   t.identifier,
+		fields:       append(t.fields[:len(t.fields):len(t.fields)], fields...),
+		conn:         t.conn,
+	}
+}
+
+func (t *Core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if t.Enabled(ent.Level) {
+		return ce.AddCore(ent, t)
+	}
+
+	return ce
+}
+
+func (t *Core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range t.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	keys := make([]string, 0, len(enc.Fields))
+	for k := range enc.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	buf := bufferPool.Get()
+	defer buf.Free()
+	identifier := t.identifier
+	if ent.LoggerName != "" {
+		identifier = ent.LoggerName
+		appendField(buf, "LOGZAP_MODULE", ent.LoggerName)
+	}
+	appendField(buf, "MESSAGE", ent.Message)
+	appendField(buf, "PRIORITY", strconv.Itoa(Priority(ent.Level)))
+	appendField(buf, "SYSLOG_IDENTIFIER", identifier)
+	if ent.Caller.Defined {
+		appendField(buf, "CODE_FILE", ent.Caller.File)
+		appendField(buf, "CODE_LINE", strconv.Itoa(ent.Caller.Line))
+		if ent.Caller.Function != "" {
+			appendField(buf, "CODE_FUNC", ent.Caller.Function)
+		}
+	}
+	if ent.Stack != "" {
+		appendField(buf, "STACKTRACE", ent.Stack)
+	}
+	for _, k := range keys {
+		appendField(buf, FieldName(k), value(enc.Fields[k]))
+	}
+
+	return t.conn.send(buf.Bytes())
+}
+
+func (t *Core) Sync() error {
+	return nil
+}
+
+// Close closes the socket, the core is unusable after that.
+func (t *Core) Close(_ context.Context) error {
+	return t.conn.close()
+}
+
+// value return the journal value of a field, the strings are kept and the
+// others are JSON.
+func value(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(b)
+}
+
+// appendField appends a field of the native protocol, the value with newlines
+// is written as its little-endian 64-bit length and the raw bytes.
+func appendField(buf *buffer.Buffer, name, value string) {
+	buf.AppendString(name)
+	if !strings.ContainsRune(value, '\n') {
+		buf.AppendByte('=')
+		buf.AppendString(value)
+		buf.AppendByte('\n')
+
+		return
+	}
+	buf.AppendByte('\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	_, _ = buf.Write(size[:])
+	buf.AppendString(value)
+	buf.AppendByte('\n')
+}
+
+// conn is the datagram socket shared by the cores.
+type conn struct {
+	mu     sync.Mutex
+	conn   *net.UnixConn
+	addr   *net.UnixAddr
+	closed bool
+}
+
+func newConn(socket string) (*conn, error) {
+	addr := &net.UnixAddr{Name: socket, Net: "unixgram"}
+	// an unbound socket sends to the address of each datagram
+	c, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: "", Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+
+	return &conn{conn: c, addr: addr}, nil
+}
+
+// send sends the payload in a datagram, or in a sealed memfd when it is too
+// large for a datagram.
+func (t *conn) send(payload []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return ErrClosed
+	}
+	_, _, err := t.conn.WriteMsgUnix(payload, nil, t.addr)
+	if err != nil && isTooLarge(err) {
+		return sendMemfd(t.conn, t.addr, payload)
+	}
+
+	return err
+}
+
+func (t *conn) close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil
+	}
+	t.closed = true
+
+	return t.conn.Close()
+}
@@ -0,0 +1,145 @@
+package journald_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kiraxie/logzap/core/journald"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type closer interface {
+	Close(ctx context.Context) error
+}
+
+// listen return a fake journal socket.
+func listen(t *testing.T) (*net.UnixConn, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return conn, path
+}
+
+// parse decodes the fields of native protocol.
+func parse(t *testing.T, payload []byte) map[string]string {
+	t.Helper()
+	fields := map[string]string{}
+	for len(payload) > 0 {
+		line := bytes.IndexByte(payload, '\n')
+		require.GreaterOrEqual(t, line, 0)
+		if eq := bytes.IndexByte(payload[:line], '='); eq >= 0 {
+			fields[string(payload[:eq])] = string(payload[eq+1 : line])
+			payload = payload[line+1:]
+			continue
+		}
+		name := string(payload[:line])
+		payload = payload[line+1:]
+		size := binary.LittleEndian.Uint64(payload[:8])
+		fields[name] = string(payload[8 : 8+size])
+		require.Equal(t, byte('\n'), payload[8+size])
+		payload = payload[9+size:]
+	}
+
+	return fields
+}
+
+func TestFieldName(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "USER_ID", journald.FieldName("user_id"))
+	require.Equal(t, "HTTP_STATUS", journald.FieldName("http.status"))
+	require.Equal(t, "PRIVATE", journald.FieldName("_private"))
+	require.Equal(t, "F_1ST", journald.FieldName("1st"))
+	require.Equal(t, "F_", journald.FieldName("é"))
+	require.Equal(t, "F_MESSAGE", journald.FieldName("message"))
+	require.Equal(t, "F_SYSLOG_IDENTIFIER", journald.FieldName("_SYSLOG_IDENTIFIER"))
+	require.Equal(t, "MESSAGES", journald.FieldName("messages"))
+}
+
+func TestJournaldReserved(t *testing.T) {
+	t.Parallel()
+	server, path := listen(t)
+	core, err := journald.New(context.Background(), prometheus.NewRegistry(), "journald://"+path+"?identifier=myapp")
+	require.NoError(t, err)
+	defer core.(closer).Close(context.Background())
+
+	// the fields of entry can't spoof the ones of core
+	require.NoError(t, core.Write(zapcore.Entry{Level: zapcore.InfoLevel, LoggerName: "audit", Message: "login"}, []zapcore.Field{
+		zap.String("message", "spoofed"),
+		zap.String("priority", "0"),
+		zap.String("syslog.identifier", "sshd"),
+		zap.String("logzap_module", "root"),
+		zap.String("code_file", "main.go"),
+	}))
+	buf := make([]byte, 1<<16)
+	require.NoError(t, server.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, err := server.Read(buf)
+	require.NoError(t, err)
+	fields := parse(t, buf[:n])
+	require.Equal(t, "login", fields["MESSAGE"])
+	require.Equal(t, "6", fields["PRIORITY"])
+	require.Equal(t, "audit", fields["SYSLOG_IDENTIFIER"])
+	require.Equal(t, "audit", fields["LOGZAP_MODULE"])
+	require.NotContains(t, fields, "CODE_FILE")
+	require.Equal(t, "spoofed", fields["F_MESSAGE"])
+	require.Equal(t, "0", fields["F_PRIORITY"])
+	require.Equal(t, "sshd", fields["F_SYSLOG_IDENTIFIER"])
+	require.Equal(t, "root", fields["F_LOGZAP_MODULE"])
+	require.Equal(t, "main.go", fields["F_CODE_FILE"])
+}
+
+func TestJournald(t *testing.T) {
+	t.Parallel()
+	server, path := listen(t)
+	core, err := journald.New(context.Background(), prometheus.NewRegistry(), "journald://"+path+"?identifier=myapp")
+	require.NoError(t, err)
+	logger := zap.New(core, zap.AddCaller())
+
+	read := func() map[string]string {
+		buf := make([]byte, 1<<16)
+		require.NoError(t, server.SetReadDeadline(time.Now().Add(5*time.Second)))
+		n, err := server.Read(buf)
+		require.NoError(t, err)
+
+		return parse(t, buf[:n])
+	}
+
+	logger.Info("started")
+	fields := read()
+	require.Equal(t, "started", fields["MESSAGE"])
+	require.Equal(t, "6", fields["PRIORITY"])
+	require.Equal(t, "myapp", fields["SYSLOG_IDENTIFIER"])
+	require.NotContains(t, fields, "LOGZAP_MODULE")
+	require.Equal(t, "journald_test.go", filepath.Base(fields["CODE_FILE"]))
+
+	logger.Named("audit").With(zap.String("userId", "u1")).Error(
+		"denied\nby policy",
+		zap.Int("http.status", 403),
+		zap.Error(errors.New("forbidden")),
+		zap.Strings("roles", []string{"a", "b"}),
+	)
+	fields = read()
+	require.Equal(t, "denied\nby policy", fields["MESSAGE"])
+	require.Equal(t, "3", fields["PRIORITY"])
+	require.Equal(t, "audit", fields["SYSLOG_IDENTIFIER"])
+	require.Equal(t, "audit", fields["LOGZAP_MODULE"])
+	require.Equal(t, "u1", fields["USERID"])
+	require.Equal(t, "403", fields["HTTP_STATUS"])
+	require.Equal(t, "forbidden", fields["ERROR"])
+	require.Equal(t, `["a","b"]`, fields["ROLES"])
+
+	require.NoError(t, core.(closer).Close(context.Background()))
+	require.ErrorIs(t, core.Write(zapcore.Entry{}, nil), journald.ErrClosed)
+	require.NoError(t, core.(closer).Close(context.Background()))
+}
@@ -0,0 +1,37 @@
+//go:build linux
+
+package journald
+
+import (
+	"errors"
+	"net"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+func isTooLarge(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS)
+}
+
+// sendMemfd writes the payload to a sealed memfd and sends its descriptor, as
+// sd_journal_send does for the payloads over the datagram limit.
+func sendMemfd(conn *net.UnixConn, addr *net.UnixAddr, payload []byte) error {
+	fd, err := unix.MemfdCreate("logzap-journal", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return err
+	}
+	f := os.NewFile(uintptr(fd), "logzap-journal")
+	defer f.Close()
+	if _, err := f.Write(payload); err != nil {
+		return err
+	}
+	if _, err := unix.FcntlInt(f.Fd(), unix.F_ADD_SEALS,
+		unix.F_SEAL_SHRINK|unix.F_SEAL_GROW|unix.F_SEAL_WRITE|unix.F_SEAL_SEAL); err != nil {
+		return err
+	}
+	_, _, err = conn.WriteMsgUnix(nil, unix.UnixRights(int(f.Fd())), addr)
+
+	return err
+}
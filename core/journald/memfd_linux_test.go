@@ -0,0 +1,53 @@
+//go:build linux
+
+package journald_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/kiraxie/logzap/core/journald"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestJournaldMemfd(t *testing.T) {
+	t.Parallel()
+	server, path := listen(t)
+	core, err := journald.New(context.Background(), prometheus.NewRegistry(), "journald://"+path)
+	require.NoError(t, err)
+	defer core.(closer).Close(context.Background())
+
+	large := strings.Repeat("x", 4<<20)
+	zap.New(core).Info("large", zap.String("payload", large))
+
+	oob := make([]byte, syscall.CmsgSpace(4))
+	require.NoError(t, server.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, oobn, _, _, err := server.ReadMsgUnix(nil, oob)
+	require.NoError(t, err)
+	require.Zero(t, n)
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	require.NoError(t, err)
+	require.Len(t, fds, 1)
+	f := os.NewFile(uintptr(fds[0]), "memfd")
+	defer f.Close()
+	// the offset is shared with the sender, journald maps the memfd instead
+	payload, err := io.ReadAll(io.NewSectionReader(f, 0, 1<<30))
+	require.NoError(t, err)
+
+	fields := parse(t, payload)
+	require.Equal(t, "large", fields["MESSAGE"])
+	require.Equal(t, large, fields["PAYLOAD"])
+	// the memfd is sealed against writes
+	_, err = f.WriteAt([]byte("y"), 0)
+	require.Error(t, err)
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+
+package journald
+
+import (
+	"errors"
+	"net"
+)
+
+func isTooLarge(error) bool {
+	return false
+}
+
+// sendMemfd is never called since memfd is linux only, where journald runs.
+func sendMemfd(_ *net.UnixConn, _ *net.UnixAddr, _ []byte) error {
+	return errors.New("journald: memfd is not supported")
+}
@@ -6,6 +6,7 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	retry "github.com/cenkalti/backoff/v4"
@@ -21,7 +22,35 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
-var ErrChannelFull = fmt.Errorf("channel full")
+// ModuleName is the module of the logs of promtail client when the core is
+// built by logzap, the entries of it and its submodules are never shipped to
+// Loki to avoid feedback loops.
+const ModuleName = "logzap.loki"
+
+type loggerKey struct{}
+
+// WithLogger return a copy of ctx carrying the logger of promtail client, the
+// client logs to stderr in logfmt if ctx carries none.
+func WithLogger(ctx context.Context, logger log.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+func loggerFromContext(ctx context.Context) log.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(log.Logger); ok {
+		return logger
+	}
+
+	return log.NewLogfmtLogger(os.Stderr)
+}
+
+// SyncTimeout bounds the push of pending batches by Sync, the batches which
+// are not pushed in time are dropped.
+const SyncTimeout = 5 * time.Second
+
+var (
+	ErrChannelFull = fmt.Errorf("channel full")
+	ErrClosed      = fmt.Errorf("client closed")
+)
 
 func defaultPromtailConfig() promtail.Config {
 	return promtail.Config{
@@ -37,13 +66,18 @@ func defaultPromtailConfig() promtail.Config {
 }
 
 type Client struct {
-	ctx context.Context
-	promtail.Client
+	ctx     context.Context
+	mu      sync.RWMutex
+	client  promtail.Client
+	metrics *promtail.Metrics
+	config  promtail.Config
+	dryRun  bool
 	log.Logger
 	encoder zapcore.Encoder
 	label   model.LabelSet
 }
 
+// loki+https://host/loki/api/v1/push?name=foo&encoding=json&label.job=boo
 func New(
 	ctx context.Context,
 	registry prometheus.Registerer,
@@ -56,11 +90,10 @@ func New(
 	t := &Client{
 		ctx:    ctx,
 		label:  model.LabelSet{},
-		Logger: log.NewLogfmtLogger(os.Stderr),
+		Logger: loggerFromContext(ctx),
 	}
 	name := ""
 	encoding := ""
-	dryRun := false
 	for k, v := range u.Query() {
 		switch {
 		case k == "name" && len(v) != 0:
@@ -68,18 +101,19 @@ func New(
 		case k == "encoding" && len(v) != 0:
 			encoding = v[0]
 		case k == "dryRun" && (len(v) == 0 || v[0] == "true"):
-			dryRun = true
+			t.dryRun = true
 		case strings.HasPrefix(k, "label.") && len(v) != 0:
 			t.label[model.LabelName(strings.TrimPrefix(k, "label."))] = model.LabelValue(v[0])
 		default:
 		}
 	}
 	u.RawQuery = ""
-	promtailConf := defaultPromtailConfig()
-	promtailConf.URL = flagext.URLValue{URL: u}
-	promtailConf.Name = name
+	t.config = defaultPromtailConfig()
+	t.config.URL = flagext.URLValue{URL: u}
+	t.config.Name = name
+	t.metrics = promtail.NewMetrics(registry)
 
-	if t.Client, err = newPromtailClient(registry, promtailConf, t.Logger, dryRun); err != nil {
+	if t.client, err = newPromtailClient(t.metrics, t.config, t.Logger, t.dryRun); err != nil {
 		return nil, err
 	}
 
@@ -96,6 +130,10 @@ func (t *Client) Enabled(zapcore.Level) bool {
 }
 
 func (t *Client) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.LoggerName == ModuleName || strings.HasPrefix(ent.LoggerName, ModuleName+".") {
+		return ce
+	}
+
 	return ce.AddCore(ent, t)
 }
 
@@ -116,15 +154,7 @@ func (t *Client) Write(ent zapcore.Entry, fields []zapcore.Field) error {
 	}
 
 	return retry.Retry(func() error {
-		select {
-		case <-t.ctx.Done():
-			return nil
-		case t.Chan() <- e:
-			return nil
-		default:
-		}
-
-		return ErrChannelFull
+		return t.send(e)
 	}, retry.WithContext(&retry.ExponentialBackOff{
 		InitialInterval:     100 * time.Millisecond,
 		RandomizationFactor: 0,
@@ -136,19 +166,92 @@ func (t *Client) Write(ent zapcore.Entry, fields []zapcore.Field) error {
 	}, t.ctx))
 }
 
+// send hands the entry to the promtail client.
+func (t *Client) send(e api.Entry) error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.client == nil {
+		return retry.Permanent(ErrClosed)
+	}
+	select {
+	case <-t.ctx.Done():
+		return nil
+	case t.client.Chan() <- e:
+		return nil
+	default:
+	}
+
+	return ErrChannelFull
+}
+
+// Sync pushes the pending batches as Flush within SyncTimeout.
 func (t *Client) Sync() error {
-	t.Client.Stop()
+	ctx, cancel := context.WithTimeout(t.ctx, SyncTimeout)
+	defer cancel()
+
+	return t.Flush(ctx)
+}
+
+// Flush replaces the promtail client by a new one so the core keeps shipping
+// entries, then pushes the pending batches by stopping the previous client
+// gracefully. The writes go to the new client meanwhile.
+func (t *Client) Flush(ctx context.Context) error {
+	client, err := newPromtailClient(t.metrics, t.config, t.Logger, t.dryRun)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	old := t.client
+	if old != nil {
+		t.client = client
+	}
+	t.mu.Unlock()
+	if old == nil {
+		client.StopNow()
+
+		return ErrClosed
+	}
 
-	return nil
+	return stop(ctx, old)
+}
+
+// Close pushes the pending batches and stops the core, the batches which are
+// not pushed before ctx is done are dropped.
+func (t *Client) Close(ctx context.Context) error {
+	t.mu.Lock()
+	old := t.client
+	t.client = nil
+	t.mu.Unlock()
+	if old == nil {
+		return nil
+	}
+
+	return stop(ctx, old)
+}
+
+func stop(ctx context.Context, client promtail.Client) error {
+	done := make(chan struct{})
+	go func() {
+		client.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		client.StopNow()
+		<-done
+
+		return ctx.Err()
+	}
 }
 
 func newPromtailClient(
-	registry prometheus.Registerer,
+	metrics *promtail.Metrics,
 	config promtail.Config,
 	logger log.Logger,
 	dryRun bool,
 ) (client promtail.Client, err error) {
-	metrics := promtail.NewMetrics(registry)
 	if dryRun {
 		client, err = promtail.NewLogger(metrics, logger, config)
 	} else {
@@ -174,5 +277,5 @@ func newZapEncoder(encoding string) (enc zapcore.Encoder) {
 		enc = zapcore.NewJSONEncoder(zEncConf)
 	}
 
-	return 
+	return
 }
@@ -2,13 +2,18 @@ package loki_test
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/go-kit/log"
 	"github.com/kiraxie/logzap/core/loki"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 func TestPromtail(t *testing.T) {
@@ -25,3 +30,115 @@ func TestPromtail(t *testing.T) {
 	logger.Named("foo").Info("123")
 	time.Sleep(500 * time.Millisecond)
 }
+
+func TestPromtailLifecycle(t *testing.T) {
+	t.Parallel()
+	core, err := loki.New(
+		context.Background(),
+		prometheus.NewRegistry(),
+		"http://example.com:3100/loki/api/v1/push?dryRun=true&label.instance=foo",
+	)
+	require.NoError(t, err)
+	require.IsType(t, &loki.Client{}, core)
+	client := core.(*loki.Client)
+	logger := zap.New(core)
+	logger.Info("before sync")
+	require.NoError(t, logger.Sync())
+	require.NoError(t, core.Write(zapcore.Entry{Message: "after sync"}, nil))
+	require.NoError(t, client.Flush(context.Background()))
+	require.NoError(t, client.Close(context.Background()))
+	require.ErrorIs(t, core.Write(zapcore.Entry{Message: "after close"}, nil), loki.ErrClosed)
+	require.ErrorIs(t, client.Flush(context.Background()), loki.ErrClosed)
+	require.NoError(t, client.Close(context.Background()))
+}
+
+func TestPromtailFeedback(t *testing.T) {
+	t.Parallel()
+	core, err := loki.New(
+		loki.WithLogger(context.Background(), log.NewNopLogger()),
+		prometheus.NewRegistry(),
+		"http://example.com:3100/loki/api/v1/push?dryRun=true",
+	)
+	require.NoError(t, err)
+	defer core.(*loki.Client).Close(context.Background())
+	require.Nil(t, core.Check(zapcore.Entry{LoggerName: loki.ModuleName}, nil))
+	require.Nil(t, core.Check(zapcore.Entry{LoggerName: loki.ModuleName + ".client"}, nil))
+	require.NotNil(t, core.Check(zapcore.Entry{LoggerName: "logzap.lokiish"}, nil))
+	require.NotNil(t, core.Check(zapcore.Entry{LoggerName: "db"}, nil))
+}
+
+func TestPromtailRejected(t *testing.T) {
+	t.Parallel()
+	var pushes atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		pushes.Add(1)
+		http.Error(w, "bad request", http.StatusBadRequest)
+	}))
+	defer server.Close()
+	core, err := loki.New(
+		loki.WithLogger(context.Background(), log.NewNopLogger()),
+		prometheus.NewRegistry(),
+		server.URL+"/loki/api/v1/push?label.job=test",
+	)
+	require.NoError(t, err)
+	client := core.(*loki.Client)
+	logger := zap.New(core)
+
+	// Sync pushes the pending batch, which is rejected, and keeps the core
+	// shipping
+	logger.Info("before sync")
+	require.NoError(t, logger.Sync())
+	require.Equal(t, int32(1), pushes.Load())
+
+	// nothing is pushed without pending entries
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		require.NoError(t, logger.Sync())
+	}
+	require.Less(t, time.Since(start), time.Second)
+	require.Equal(t, int32(1), pushes.Load())
+
+	logger.Info("after sync")
+	require.NoError(t, client.Flush(context.Background()))
+	require.Equal(t, int32(2), pushes.Load())
+	logger.Info("after flush")
+	require.NoError(t, client.Close(context.Background()))
+	require.Equal(t, int32(3), pushes.Load())
+	require.ErrorIs(t, client.Flush(context.Background()), loki.ErrClosed)
+}
+
+func TestPromtailFlushSlow(t *testing.T) {
+	t.Parallel()
+	pushing := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		select {
+		case pushing <- struct{}{}:
+		default:
+		}
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	core, err := loki.New(
+		loki.WithLogger(context.Background(), log.NewNopLogger()),
+		prometheus.NewRegistry(),
+		server.URL+"/loki/api/v1/push?label.job=test",
+	)
+	require.NoError(t, err)
+	client := core.(*loki.Client)
+
+	require.NoError(t, core.Write(zapcore.Entry{Message: "before flush"}, nil))
+	flushed := make(chan error)
+	go func() { flushed <- client.Flush(context.Background()) }()
+	<-pushing
+
+	// the writes go to the new client while the previous one is pushing
+	start := time.Now()
+	require.NoError(t, core.Write(zapcore.Entry{Message: "during flush"}, nil))
+	require.Less(t, time.Since(start), 100*time.Millisecond)
+
+	close(release)
+	require.NoError(t, <-flushed)
+	require.NoError(t, client.Close(context.Background()))
+}
@@ -0,0 +1,426 @@
+// Package syslog implements a core which sends the entries to a syslog
+// server in the format of RFC 5424 or RFC 3164.
+package syslog
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kiraxie/logzap/filter"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	ErrInvalidOption = errors.New("invalid syslog option")
+	ErrClosed        = errors.New("syslog closed")
+)
+
+const (
+	FormatRFC5424 = "rfc5424"
+	FormatRFC3164 = "rfc3164"
+
+	dialTimeout  = 5 * time.Second
+	writeTimeout = 5 * time.Second
+	minBackoff   = 100 * time.Millisecond
+	maxBackoff   = 30 * time.Second
+)
+
+var facilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3,
+	"auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+var bufferPool = buffer.NewPool()
+
+// Severity return the syslog severity of level, the levels below DebugLevel
+// are debug too.
+func Severity(lv zapcore.Level) int {
+	switch {
+	case lv <= zapcore.DebugLevel:
+		return 7 // debug
+	case lv == zapcore.InfoLevel:
+		return 6 // informational
+	case lv == zapcore.WarnLevel:
+		return 4 // warning
+	case lv == zapcore.ErrorLevel:
+		return 3 // error
+	case lv == zapcore.DPanicLevel:
+		return 2 // critical
+	case lv == zapcore.PanicLevel:
+		return 1 // alert
+	default:
+		return 0 // emergency
+	}
+}
+
+// Core is a zapcore.Core which sends the entries to a syslog server.
+type Core struct {
+	zapcore.LevelEnabler
+	enc    zapcore.Encoder
+	header *header
+	conn   *conn
+}
+
+// header is the part of message before the content.
+type header struct {
+	format   string
+	facility int
+	hostname string
+	app      string
+	pid      int
+}
+
+// syslog://host:514?format=rfc5424&facility=local0&app=foo
+//
+// The scheme selects the transport, syslog and udp are UDP, tcp and tls are
+// TCP with octet-counting framing, and unix is the local socket such as
+// syslog+unix:///dev/log.
+func New(
+	_ context.Context,
+	_ prometheus.Registerer,
+	rawURL string,
+) (zapcore.Core, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	h := &header{
+		format:   FormatRFC5424,
+		facility: facilities["user"],
+		app:      filepath.Base(os.Args[0]),
+		pid:      os.Getpid(),
+	}
+	if v := query.Get("format"); v != "" {
+		if v != FormatRFC5424 && v != FormatRFC3164 {
+			return nil, fmt.Errorf("%w: format %q", ErrInvalidOption, v)
+		}
+		h.format = v
+	}
+	if v := query.Get("facility"); v != "" {
+		var ok bool
+		if h.facility, ok = facilities[strings.ToLower(v)]; !ok {
+			return nil, fmt.Errorf("%w: facility %q", ErrInvalidOption, v)
+		}
+	}
+	if v := query.Get("app"); v != "" {
+		h.app = v
+	}
+	if h.hostname = query.Get("hostname"); h.hostname == "" {
+		if h.hostname, err = os.Hostname(); err != nil || h.hostname == "" {
+			h.hostname = "-"
+		}
+	}
+	c, err := newConn(u)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := zap.NewProductionEncoderConfig()
+	cfg.TimeKey, cfg.LevelKey, cfg.NameKey = "", "", ""
+	var enc zapcore.Encoder
+	switch query.Get("encoder") {
+	case "console":
+		enc = zapcore.NewConsoleEncoder(cfg)
+	default:
+		enc = zapcore.NewJSONEncoder(cfg)
+	}
+	if query.Get("filter") == "true" {
+		enc = &filter.FilterEncoder{Encoder: enc}
+	}
+
+	return &Core{LevelEnabler: zapcore.DebugLevel, enc: enc, header: h, conn: c}, nil
+}
+
+func (t *Core) With(fields []zapcore.Field) zapcore.Core {
+	enc := t.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	return &Core{LevelEnabler: t.LevelEnabler, enc: enc, header: t.header, conn: t.conn}
+}
+
+func (t *Core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if t.Enabled(ent.Level) {
+		return ce.AddCore(ent, t)
+	}
+
+	return ce
+}
+
+func (t *Core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	content, err := t.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	defer content.Free()
+	msg := bufferPool.Get()
+	defer msg.Free()
+	t.header.append(msg, ent)
+	msg.Write(trimNewline(content.Bytes()))
+
+	return t.conn.write(msg.Bytes())
+}
+
+func (t *Core) Sync() error {
+	return nil
+}
+
+// Close closes the connection, the core is unusable after that.
+func (t *Core) Close(_ context.Context) error {
+	return t.conn.close()
+}
+
+// append appends the header of entry to buf, the module is the MSGID of RFC
+// 5424 and the TAG of RFC 3164 is "app[pid]".
+func (t *header) append(buf *buffer.Buffer, ent zapcore.Entry) {
+	buf.AppendByte('<')
+	buf.AppendInt(int64(t.facility*8 + Severity(ent.Level)))
+	buf.AppendByte('>')
+	if t.format == FormatRFC3164 {
+		buf.AppendTime(ent.Time, time.Stamp)
+		buf.AppendByte(' ')
+		buf.AppendString(t.hostname)
+		buf.AppendByte(' ')
+		buf.AppendString(t.app)
+		buf.AppendByte('[')
+		buf.AppendInt(int64(t.pid))
+		buf.AppendString("]: ")
+		if ent.LoggerName != "" {
+			buf.AppendString(ent.LoggerName)
+			buf.AppendString(": ")
+		}
+
+		return
+	}
+	buf.AppendString("1 ")
+	buf.AppendTime(ent.Time, "2006-01-02T15:04:05.000000Z07:00")
+	buf.AppendByte(' ')
+	buf.AppendString(field(t.hostname, 255))
+	buf.AppendByte(' ')
+	buf.AppendString(field(t.app, 48))
+	buf.AppendByte(' ')
+	buf.AppendInt(int64(t.pid))
+	buf.AppendByte(' ')
+	buf.AppendString(field(ent.LoggerName, 32))
+	buf.AppendString(" - ")
+}
+
+// field return the header field of RFC 5424, which is printable ASCII
+// without space in max bytes, or "-" when empty.
+func field(s string, max int) string {
+	s = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+
+		return r
+	}, s)
+	if len(s) > max {
+		s = s[:max]
+	}
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
+
+func trimNewline(p []byte) []byte {
+	for len(p) > 0 && (p[len(p)-1] == '\n' || p[len(p)-1] == '\r') {
+		p = p[:len(p)-1]
+	}
+
+	return p
+}
+
+// conn is the connection to the server shared by the cores, it reconnects
+// with exponential backoff when a write fails.
+type conn struct {
+	mu      sync.Mutex
+	dial    func() (net.Conn, error)
+	framed  bool
+	conn    net.Conn
+	backoff time.Duration
+	retry   time.Time
+	closed  bool
+}
+
+func newConn(u *url.URL) (*conn, error) {
+	t := &conn{}
+	switch u.Scheme {
+	case "syslog", "udp":
+		addr := hostPort(u.Host, "514")
+		t.dial = func() (net.Conn, error) { return net.DialTimeout("udp", addr, dialTimeout) }
+	case "tcp":
+		addr := hostPort(u.Host, "514")
+		t.dial = func() (net.Conn, error) { return net.DialTimeout("tcp", addr, dialTimeout) }
+		t.framed = true
+	case "tls":
+		addr := hostPort(u.Host, "6514")
+		cfg, err := tlsConfig(u)
+		if err != nil {
+			return nil, err
+		}
+		dialer := &net.Dialer{Timeout: dialTimeout}
+		t.dial = func() (net.Conn, error) { return tls.DialWithDialer(dialer, "tcp", addr, cfg) }
+		t.framed = true
+	case "unix":
+		path := u.Host + u.Path
+		t.dial = func() (net.Conn, error) {
+			c, err := net.DialTimeout("unixgram", path, dialTimeout)
+			if err != nil {
+				c, err = net.DialTimeout("unix", path, dialTimeout)
+				t.framed = err == nil
+			}
+
+			return c, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: transport %q", ErrInvalidOption, u.Scheme)
+	}
+	// the server may be started later, the first entry dials again
+	t.mu.Lock()
+	_ = t.connect()
+	t.mu.Unlock()
+
+	return t, nil
+}
+
+// tlsConfig return the TLS config of URL, the parameter ca is the path of
+// PEM root certificates and insecure skips the verification.
+func tlsConfig(u *url.URL) (*tls.Config, error) {
+	cfg := &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}
+	if v := u.Query().Get("ca"); v != "" {
+		pem, err := os.ReadFile(v)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w: ca %q", ErrInvalidOption, v)
+		}
+	}
+	cfg.InsecureSkipVerify = u.Query().Get("insecure") == "true" //nolint:gosec
+
+	return cfg, nil
+}
+
+func hostPort(host, port string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	if host == "" {
+		host = "localhost"
+	}
+
+	return net.JoinHostPort(strings.Trim(host, "[]"), port)
+}
+
+// connect dials the server unless it is in backoff, it must be called with
+// mu held.
+func (t *conn) connect() error {
+	if time.Now().Before(t.retry) {
+		return fmt.Errorf("syslog: reconnecting in %s", time.Until(t.retry).Round(time.Millisecond))
+	}
+	c, err := t.dial()
+	if err != nil {
+		t.fail()
+
+		return err
+	}
+	t.conn, t.backoff, t.retry = c, 0, time.Time{}
+
+	return nil
+}
+
+// fail drops the connection and backs off, it must be called with mu held.
+func (t *conn) fail() {
+	if t.conn != nil {
+		_ = t.conn.Close()
+		t.conn = nil
+	}
+	switch {
+	case t.backoff == 0:
+		t.backoff = minBackoff
+	case t.backoff < maxBackoff:
+		t.backoff = min(2*t.backoff, maxBackoff)
+	}
+	t.retry = time.Now().Add(t.backoff)
+}
+
+// write sends the message, it reconnects once at once when the connection is
+// broken since the server may have restarted.
+func (t *conn) write(msg []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return ErrClosed
+	}
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if t.conn == nil {
+			if err = t.connect(); err != nil {
+				return err
+			}
+		}
+		if err = t.send(msg); err == nil {
+			return nil
+		}
+		_ = t.conn.Close()
+		t.conn = nil
+	}
+	t.fail()
+
+	return err
+}
+
+// send must be called with mu held and conn connected.
+func (t *conn) send(msg []byte) error {
+	_ = t.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if !t.framed {
+		_, err := t.conn.Write(msg)
+
+		return err
+	}
+	// octet-counting framing of RFC 6587
+	buf := bufferPool.Get()
+	defer buf.Free()
+	buf.AppendInt(int64(len(msg)))
+	buf.AppendByte(' ')
+	buf.Write(msg)
+	_, err := t.conn.Write(buf.Bytes())
+
+	return err
+}
+
+func (t *conn) close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil
+	}
+	t.closed = true
+	if t.conn == nil {
+		return nil
+	}
+
+	return t.conn.Close()
+}
@@ -0,0 +1,148 @@
+package syslog_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kiraxie/logzap/core/syslog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type closer interface {
+	Close(ctx context.Context) error
+}
+
+// readFrame reads a message of octet-counting framing.
+func readFrame(r *bufio.Reader) (string, error) {
+	size, err := r.ReadString(' ')
+	if err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(size, " "))
+	if err != nil {
+		return "", err
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return "", err
+	}
+
+	return string(msg), nil
+}
+
+func TestSeverity(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, 7, syslog.Severity(zapcore.DebugLevel-1))
+	require.Equal(t, 7, syslog.Severity(zapcore.DebugLevel))
+	require.Equal(t, 6, syslog.Severity(zapcore.InfoLevel))
+	require.Equal(t, 4, syslog.Severity(zapcore.WarnLevel))
+	require.Equal(t, 3, syslog.Severity(zapcore.ErrorLevel))
+	require.Equal(t, 2, syslog.Severity(zapcore.DPanicLevel))
+	require.Equal(t, 1, syslog.Severity(zapcore.PanicLevel))
+	require.Equal(t, 0, syslog.Severity(zapcore.FatalLevel))
+}
+
+func TestSyslogUDP(t *testing.T) {
+	t.Parallel()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc.Close()
+	core, err := syslog.New(
+		context.Background(),
+		prometheus.NewRegistry(),
+		"syslog://"+pc.LocalAddr().String()+"?facility=local0&app=myapp&hostname=host1",
+	)
+	require.NoError(t, err)
+	defer core.(closer).Close(context.Background())
+
+	zap.New(core).Named("db").Warn("slow query", zap.Int("ms", 1200))
+	buf := make([]byte, 2048)
+	require.NoError(t, pc.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := pc.ReadFrom(buf)
+	require.NoError(t, err)
+	msg := string(buf[:n])
+	// local0 * 8 + warning
+	require.True(t, strings.HasPrefix(msg, "<132>1 "), msg)
+	parts := strings.SplitN(msg, " ", 8)
+	require.Len(t, parts, 8)
+	_, err = time.Parse(time.RFC3339Nano, parts[1])
+	require.NoError(t, err)
+	require.Equal(t, []string{"host1", "myapp"}, parts[2:4])
+	require.Equal(t, []string{"db", "-"}, parts[5:7])
+	require.Contains(t, parts[7], `"msg":"slow query"`)
+	require.Contains(t, parts[7], `"ms":1200`)
+	require.False(t, strings.HasSuffix(msg, "\n"))
+}
+
+func TestSyslogTCP(t *testing.T) {
+	t.Parallel()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	conns := make(chan net.Conn, 2)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns <- c
+		}
+	}()
+	core, err := syslog.New(
+		context.Background(),
+		prometheus.NewRegistry(),
+		"tcp://"+ln.Addr().String()+"?format=rfc3164&app=myapp&hostname=host1",
+	)
+	require.NoError(t, err)
+	logger := zap.New(core)
+
+	logger.Info("line1\nline2")
+	first := <-conns
+	msg, err := readFrame(bufio.NewReader(first))
+	require.NoError(t, err)
+	require.Regexp(t, `^<14>\w{3} [ \d]\d \d\d:\d\d:\d\d host1 myapp\[\d+\]: {"msg":"line1\\nline2"}$`, msg)
+
+	// the server drops the connection, the core reconnects
+	require.NoError(t, first.Close())
+	var second net.Conn
+	require.Eventually(t, func() bool {
+		logger.Named("audit").Error("retry")
+		select {
+		case second = <-conns:
+			return true
+		default:
+			return false
+		}
+	}, 5*time.Second, 50*time.Millisecond)
+	msg, err = readFrame(bufio.NewReader(second))
+	require.NoError(t, err)
+	require.Contains(t, msg, "myapp[")
+	require.Contains(t, msg, "]: audit: ")
+	require.NoError(t, second.Close())
+
+	require.NoError(t, core.(closer).Close(context.Background()))
+	require.ErrorIs(t, core.Write(zapcore.Entry{}, nil), syslog.ErrClosed)
+	require.NoError(t, core.(closer).Close(context.Background()))
+}
+
+func TestSyslogInvalid(t *testing.T) {
+	t.Parallel()
+	for _, rawURL := range []string{
+		"syslog://127.0.0.1:514?format=rfc1",
+		"syslog://127.0.0.1:514?facility=local9",
+		"http://127.0.0.1:514",
+	} {
+		_, err := syslog.New(context.Background(), prometheus.NewRegistry(), rawURL)
+		require.ErrorIs(t, err, syslog.ErrInvalidOption, rawURL)
+	}
+}
@@ -4,11 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/kiraxie/logzap/core/buffer"
 	"github.com/kiraxie/logzap/core/console"
+	"github.com/kiraxie/logzap/core/file"
+	"github.com/kiraxie/logzap/core/journald"
 	"github.com/kiraxie/logzap/core/loki"
+	"github.com/kiraxie/logzap/core/syslog"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap/zapcore"
 )
@@ -23,12 +29,59 @@ type CoreConstructor func(ctx context.Context, registry prometheus.Registerer, u
 var (
 	mu               sync.RWMutex
 	_coreConstructor = map[string]CoreConstructor{
-		"buffer":  buffer.New,
-		"console": console.New,
-		"loki":    loki.New,
+		"buffer":   buffer.New,
+		"console":  console.New,
+		"file":     file.New,
+		"journald": journald.New,
+		"loki":     loki.New,
+		"syslog":   syslog.New,
 	}
 )
 
+// RegisterCore registers a constructor for the given URL scheme.
+// An existing constructor with the same scheme is replaced.
+func RegisterCore(scheme string, constructor CoreConstructor) {
+	mu.Lock()
+	defer mu.Unlock()
+	_coreConstructor[strings.ToLower(scheme)] = constructor
+}
+
+// UnregisterCore removes the constructor of the given URL scheme.
+func UnregisterCore(scheme string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(_coreConstructor, strings.ToLower(scheme))
+}
+
+// lookupCore resolves the constructor of a core instance by its URL scheme.
+//
+// A scheme in the form of "core+transport" selects "core" and passes the URL
+// with "transport" scheme to the constructor, e.g. "loki+https://host" passes
+// "https://host" to the loki constructor. Unregistered schemes fall back to the
+// instance name for compatibility with configurations keyed by constructor name.
+func lookupCore(name, rawURL string) (CoreConstructor, string, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if u, err := url.Parse(rawURL); err == nil && u.Scheme != "" {
+		scheme, transport, ok := strings.Cut(u.Scheme, "+")
+		if constructor, found := _coreConstructor[scheme]; found {
+			if ok {
+				u.Scheme = transport
+				rawURL = u.String()
+			}
+
+			return constructor, rawURL, nil
+		}
+	}
+	if constructor, found := _coreConstructor[name]; found {
+		return constructor, rawURL, nil
+	}
+
+	return nil, "", fmt.Errorf("%w: %s", ErrUnsupportedCoreConstructor, rawURL)
+}
+
+// Cores is a set of core instances keyed by instance name, the value is the
+// URL whose scheme selects the registered constructor.
 type Cores map[string]string
 
 func (t Cores) MustBuild(
@@ -47,19 +100,14 @@ func (t Cores) Build(
 	ctx context.Context,
 	registry prometheus.Registerer,
 ) (core []zapcore.Core, err error) {
-	if ctx == nil {
-		ctx = context.Background()
-	}
-	mu.RLock()
-	defer mu.RUnlock()
 	core = []zapcore.Core{}
-	for name, url := range t {
-		constructor, ok := _coreConstructor[name]
-		if !ok {
-			return nil, fmt.Errorf("%w: %s", ErrUnsupportedCoreConstructor, name)
-		}
-		m, err := constructor(ctx, registry, url)
+	for _, name := range t.names() {
+		m, err := t.BuildByName(ctx, registry, name)
 		if err != nil {
+			for _, c := range core {
+				_ = closeCore(context.Background(), c)
+			}
+
 			return nil, err
 		}
 		core = append(core, m)
@@ -73,21 +121,54 @@ func (t Cores) BuildByName(
 	registry prometheus.Registerer,
 	name string,
 ) (core zapcore.Core, err error) {
-	mu.RLock()
-	defer mu.RUnlock()
-
-	url, ok := t[name]
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	rawURL, ok := t[name]
 	if !ok {
 		return nil, fmt.Errorf("%w: %s", ErrCoreNotFound, name)
 	}
-	constructor, ok := _coreConstructor[name]
-	if !ok {
-		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCoreConstructor, name)
+	constructor, rawURL, err := lookupCore(name, rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("core %q: %w", name, err)
 	}
-	core, err = constructor(ctx, registry, url)
+	rawURL, route, err := newRouteCore(rawURL)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("core %q: %w", name, err)
+	}
+	if core, err = constructor(ctx, registry, rawURL); err != nil {
+		return nil, fmt.Errorf("core %q: %w", name, err)
+	}
+	if route != nil {
+		core = route(core)
 	}
 
 	return
 }
+
+// Validate checks that every core has a registered constructor and valid
+// standard query parameters without building it.
+func (t Cores) Validate() error {
+	for _, name := range t.names() {
+		_, rawURL, err := lookupCore(name, t[name])
+		if err == nil {
+			_, _, err = newRouteCore(rawURL)
+		}
+		if err != nil {
+			return fmt.Errorf("core %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// names return the instance names in a stable order.
+func (t Cores) names() []string {
+	names := make([]string, 0, len(t))
+	for name := range t {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,110 @@
+package logzap_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/kiraxie/logzap"
+	"github.com/kiraxie/logzap/core/buffer"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestCores(t *testing.T) {
+	t.Parallel()
+	t.Run("multiple instances", func(t *testing.T) {
+		t.Parallel()
+		cores, err := logzap.Cores{
+			"foo": "buffer://foo",
+			"boo": "buffer://boo",
+		}.Build(context.Background(), prometheus.NewRegistry())
+		require.NoError(t, err)
+		require.Len(t, cores, 2)
+		require.Equal(t, "boo", cores[0].(*buffer.Buffer).Name)
+		require.Equal(t, "foo", cores[1].(*buffer.Buffer).Name)
+	})
+	t.Run("fallback to instance name", func(t *testing.T) {
+		t.Parallel()
+		core, err := logzap.Cores{"buffer": "foo"}.BuildByName(context.Background(), prometheus.NewRegistry(), "buffer")
+		require.NoError(t, err)
+		require.Equal(t, "foo", core.(*buffer.Buffer).Name)
+	})
+	t.Run("unsupported", func(t *testing.T) {
+		t.Parallel()
+		_, err := logzap.Cores{"foo": "unknown://"}.Build(context.Background(), prometheus.NewRegistry())
+		require.ErrorIs(t, err, logzap.ErrUnsupportedCoreConstructor)
+		require.Contains(t, err.Error(), `"foo"`)
+
+		_, err = logzap.Cores{}.BuildByName(context.Background(), prometheus.NewRegistry(), "foo")
+		require.ErrorIs(t, err, logzap.ErrCoreNotFound)
+	})
+}
+
+func TestRegisterCore(t *testing.T) {
+	t.Parallel()
+	errConstruct := errors.New("construct")
+	received := ""
+	logzap.RegisterCore("test-register", func(_ context.Context, _ prometheus.Registerer, url string) (zapcore.Core, error) {
+		received = url
+		if url == "https://fail" {
+			return nil, errConstruct
+		}
+
+		return zapcore.NewNopCore(), nil
+	})
+	defer logzap.UnregisterCore("test-register")
+
+	_, err := logzap.Cores{"foo": "test-register+https://example.com/push?a=b"}.Build(context.Background(), prometheus.NewRegistry())
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/push?a=b", received)
+
+	_, err = logzap.Cores{"foo": "test-register://example.com"}.Build(context.Background(), prometheus.NewRegistry())
+	require.NoError(t, err)
+	require.Equal(t, "test-register://example.com", received)
+
+	_, err = logzap.Cores{"bar": "test-register+https://fail"}.Build(context.Background(), prometheus.NewRegistry())
+	require.ErrorIs(t, err, errConstruct)
+	require.Contains(t, err.Error(), `"bar"`)
+
+	logzap.UnregisterCore("test-register")
+	_, err = logzap.Cores{"foo": "test-register://example.com"}.Build(context.Background(), prometheus.NewRegistry())
+	require.ErrorIs(t, err, logzap.ErrUnsupportedCoreConstructor)
+}
+
+func TestRouteCore(t *testing.T) {
+	t.Parallel()
+	buffers := registerTestCore(t, "test-route", func(u *url.URL) (*buffer.Buffer, error) {
+		require.Empty(t, u.RawQuery)
+
+		return &buffer.Buffer{}, nil
+	})
+
+	cores, err := logzap.Cores{
+		"all":   "test-route://all",
+		"loki":  "test-route://loki?level=warn&modules=db,http.*&exclude=db.metrics",
+		"debug": "test-route://debug?level=info",
+	}.Build(context.Background(), prometheus.NewRegistry())
+	require.NoError(t, err)
+	require.Len(t, cores, 3)
+
+	logger := logzap.New(context.Background(), prometheus.NewRegistry(), logzap.Config{Level: zapcore.DebugLevel})
+	logger.Use(zapcore.NewTee(cores...))
+	logger.Get("db").Info("db-info")
+	logger.Get("db").Warn("db-warn")
+	logger.Get("db.pool").Error("db.pool-error")
+	logger.Get("db.metrics").Error("db.metrics-error")
+	logger.Get("http").Error("http-error")
+	logger.Get("http.server").Error("http.server-error")
+	logger.Get("cache").Debug("cache-debug")
+
+	all := buffers["all"].String()
+	for _, msg := range []string{"db-info", "db-warn", "db.pool-error", "db.metrics-error", "http-error", "http.server-error", "cache-debug"} {
+		require.Contains(t, all, msg)
+	}
+	require.Equal(t, "db-warn\ndb.pool-error\nhttp.server-error\n", buffers["loki"].String())
+	require.NotContains(t, buffers["debug"].String(), "cache-debug")
+	require.Contains(t, buffers["debug"].String(), "db-info")
+}
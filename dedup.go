@@ -0,0 +1,237 @@
+package logzap
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// dedupMaxWindows caps the open windows of a core, the entries of other keys
+// are written as is while it is full.
+const dedupMaxWindows = 1024
+
+// dedupCore collapses the entries with the same level, module, message and
+// fields written within a window. The first entry is written immediately, the
+// repetitions are counted and summarized by a single entry when the window
+// closes, such as "dial failed (repeated 4213 times in 10s)".
+type dedupCore struct {
+	zapcore.Core
+	state *dedupState
+	// key encodes the entry with the context fields of core
+	key zapcore.Encoder
+}
+
+// dedupState is the open windows shared by a core and its children, they are
+// closed by a single ticker running while any window is open.
+type dedupState struct {
+	mu      sync.Mutex
+	window  time.Duration
+	keys    map[string]struct{}
+	windows map[string]*dedupWindow
+	ticking bool
+}
+
+type dedupWindow struct {
+	core   *dedupCore
+	ent    zapcore.Entry
+	fields []zapcore.Field
+	count  int
+	opened time.Time
+}
+
+// newDedupCore return a dedupCore of window, only the fields of keys are
+// compared if keys is not empty.
+func newDedupCore(core zapcore.Core, window time.Duration, keys []string) *dedupCore {
+	state := &dedupState{window: window, windows: map[string]*dedupWindow{}}
+	if len(keys) > 0 {
+		state.keys = make(map[string]struct{}, len(keys))
+		for _, k := range keys {
+			state.keys[k] = struct{}{}
+		}
+	}
+
+	return &dedupCore{
+		Core:  core,
+		state: state,
+		key: zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+			LevelKey:    "l",
+			NameKey:     "n",
+			MessageKey:  "m",
+			EncodeLevel: zapcore.LowercaseLevelEncoder,
+		}),
+	}
+}
+
+func (t *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	key := t.key.Clone()
+	for _, f := range t.state.keyFields(fields) {
+		f.AddTo(key)
+	}
+
+	return &dedupCore{Core: t.Core.With(fields), state: t.state, key: key}
+}
+
+// Check lets the wrapped core decide on its own, the repetition is decided by
+// the write since the fields are unknown here.
+func (t *dedupCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	downstream := t.Core.Check(ent, nil)
+	if downstream == nil {
+		return ce
+	}
+	w := &dedupWrite{core: t, downstream: downstream}
+	w.outer = ce.AddCore(ent, w)
+
+	return w.outer
+}
+
+// Sync writes the summaries of all open windows before syncing the wrapped core.
+func (t *dedupCore) Sync() error {
+	t.state.flush()
+
+	return t.Core.Sync()
+}
+
+func (t *dedupCore) Flush(ctx context.Context) error {
+	t.state.flush()
+
+	return flushCore(ctx, t.Core)
+}
+
+func (t *dedupCore) Close(ctx context.Context) error {
+	t.state.flush()
+
+	return closeCore(ctx, t.Core)
+}
+
+// repeated reports whether the entry repeats an open window, otherwise a new
+// window is opened by it unless there are too many.
+func (t *dedupCore) repeated(ent zapcore.Entry, fields []zapcore.Field) bool {
+	buf, err := t.key.EncodeEntry(zapcore.Entry{Level: ent.Level, LoggerName: ent.LoggerName, Message: ent.Message},
+		t.state.keyFields(fields))
+	if err != nil {
+		return false
+	}
+	key := buf.String()
+	buf.Free()
+
+	s := t.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if w, ok := s.windows[key]; ok {
+		w.count++
+
+		return true
+	}
+	if len(s.windows) >= dedupMaxWindows {
+		return false
+	}
+	s.windows[key] = &dedupWindow{
+		core:   t,
+		ent:    ent,
+		fields: append([]zapcore.Field(nil), fields...),
+		opened: time.Now(),
+	}
+	if !s.ticking {
+		s.ticking = true
+		go s.tick()
+	}
+
+	return false
+}
+
+// keyFields return the fields compared by the key.
+func (t *dedupState) keyFields(fields []zapcore.Field) []zapcore.Field {
+	if t.keys == nil {
+		return fields
+	}
+	kept := make([]zapcore.Field, 0, len(fields))
+	for _, f := range fields {
+		if _, ok := t.keys[f.Key]; ok {
+			kept = append(kept, f)
+		}
+	}
+
+	return kept
+}
+
+// tick closes the expired windows until none is open, a window lasts a
+// quarter of the window longer at most.
+func (t *dedupState) tick() {
+	ticker := time.NewTicker(max(t.window/4, time.Millisecond))
+	defer ticker.Stop()
+	for now := range ticker.C {
+		var expired []*dedupWindow
+		t.mu.Lock()
+		for key, w := range t.windows {
+			if now.Sub(w.opened) >= t.window {
+				delete(t.windows, key)
+				expired = append(expired, w)
+			}
+		}
+		idle := len(t.windows) == 0
+		t.ticking = !idle
+		t.mu.Unlock()
+
+		for _, w := range expired {
+			w.summarize()
+		}
+		if idle {
+			return
+		}
+	}
+}
+
+// flush closes all open windows and writes their summaries.
+func (t *dedupState) flush() {
+	t.mu.Lock()
+	windows := t.windows
+	t.windows = map[string]*dedupWindow{}
+	t.mu.Unlock()
+
+	for _, w := range windows {
+		w.summarize()
+	}
+}
+
+// summarize writes the summary entry of window if the entry was repeated.
+func (t *dedupWindow) summarize() {
+	if t.count == 0 {
+		return
+	}
+	ent := t.ent
+	ent.Time = time.Now()
+	ent.Message = fmt.Sprintf("%s (repeated %d times in %s)", ent.Message, t.count, t.core.state.window)
+	if ce := t.core.Core.Check(ent, nil); ce != nil {
+		ce.Write(t.fields...)
+	}
+}
+
+// dedupWrite writes an entry accepted by the wrapped core of dedupCore unless
+// it is a repetition.
+type dedupWrite struct {
+	core       *dedupCore
+	downstream *zapcore.CheckedEntry
+	// outer is the entry which carries the write, whose error output receives
+	// the errors of downstream
+	outer *zapcore.CheckedEntry
+}
+
+func (t *dedupWrite) Enabled(zapcore.Level) bool        { return true }
+func (t *dedupWrite) With([]zapcore.Field) zapcore.Core { return t }
+func (t *dedupWrite) Sync() error                       { return nil }
+func (t *dedupWrite) Check(_ zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce
+}
+
+func (t *dedupWrite) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if t.core.repeated(ent, fields) {
+		return nil
+	}
+	t.downstream.ErrorOutput = t.outer.ErrorOutput
+	t.downstream.Write(fields...)
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package logzap_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kiraxie/logzap"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestDedup(t *testing.T) {
+	t.Parallel()
+	buffers := registerTestCore(t, "test-dedup", func(u *url.URL) (*lockedBuffer, error) {
+		require.Empty(t, u.RawQuery)
+
+		return &lockedBuffer{}, nil
+	})
+
+	logger := logzap.New(context.Background(), prometheus.NewRegistry(), logzap.Config{
+		Level: zapcore.DebugLevel,
+		Cores: logzap.Cores{
+			"all":   "test-dedup://all",
+			"dedup": "test-dedup://dedup?dedup=1h",
+			"short": "test-dedup://short?dedup=50ms",
+		},
+	})
+	log := logger.Get("db")
+	for i := 0; i < 5; i++ {
+		log.Error("dial failed", zap.String("addr", "a"))
+	}
+	log.Error("dial failed", zap.String("addr", "b"))
+	log.With(zap.String("host", "a")).Error("dial failed")
+	logger.Get("http").Error("dial failed", zap.String("addr", "a"))
+
+	require.Equal(t, "dial failed\ndial failed\ndial failed\ndial failed\n", buffers["dedup"].String())
+	require.Eventually(t, func() bool {
+		return buffers["short"].String() == "dial failed\ndial failed\ndial failed\ndial failed\n"+
+			"dial failed (repeated 4 times in 50ms)\n"
+	}, time.Second, 10*time.Millisecond)
+
+	// the pending summaries are written by Sync
+	require.NoError(t, logger.Sync())
+	require.Equal(t, "dial failed\ndial failed\ndial failed\ndial failed\n"+
+		"dial failed (repeated 4 times in 1h0m0s)\n", buffers["dedup"].String())
+	require.Equal(t, 8, strings.Count(buffers["all"].String(), "\n"))
+}
+
+func TestDedupKeys(t *testing.T) {
+	t.Parallel()
+	buffers := registerTestCore(t, "test-dedup-keys", func(u *url.URL) (*lockedBuffer, error) {
+		require.Empty(t, u.RawQuery)
+
+		return &lockedBuffer{}, nil
+	})
+
+	logger := logzap.New(context.Background(), prometheus.NewRegistry(), logzap.Config{
+		Level: zapcore.DebugLevel,
+		Cores: logzap.Cores{"keys": "test-dedup-keys://keys?dedup=1h&dedupkeys=addr"},
+	})
+	log := logger.Get("db")
+	for i := 0; i < 3; i++ {
+		log.Error("dial failed", zap.String("addr", "a"), zap.Int("attempt", i))
+		log.With(zap.Int("conn", i)).Error("dial failed", zap.String("addr", "b"))
+	}
+	require.NoError(t, logger.Sync())
+	require.Equal(t, "dial failed\ndial failed\n"+
+		"dial failed (repeated 2 times in 1h0m0s)\ndial failed (repeated 2 times in 1h0m0s)\n", buffers["keys"].String())
+
+	// the entries beyond the cap of windows are written as is
+	for i := 0; i <= 1024; i++ {
+		log.Info("query", zap.Int("addr", i))
+	}
+	log.Info("query", zap.Int("addr", 1024))
+	require.Equal(t, 1024+2, strings.Count(buffers["keys"].String(), "query\n"))
+
+	require.Error(t, logger.ReloadConfig(context.Background(), logzap.Config{
+		Cores: logzap.Cores{"keys": "test-dedup-keys://keys?dedupkeys=addr"},
+	}))
+}
@@ -1,6 +1,7 @@
 package logzap
 
 import (
+	"context"
 	"sync/atomic"
 
 	"go.uber.org/zap"
@@ -29,3 +30,29 @@ func Reload(lv zapcore.Level, modules ModulesLevel) {
 func Sync() error {
 	return _global.Load().Sync()
 }
+
+// ReloadConfig reload global instance with given configuration.
+func ReloadConfig(ctx context.Context, c Config) error {
+	return _global.Load().ReloadConfig(ctx, c)
+}
+
+// Flush drains the pending entries of global instance.
+func Flush(ctx context.Context) error {
+	return _global.Load().Flush(ctx)
+}
+
+// Close closes the cores of global instance.
+func Close(ctx context.Context) error {
+	return _global.Load().Close(ctx)
+}
+
+// Watch applies the configuration file to global instance and keeps watching it.
+func Watch(ctx context.Context, path string, opts WatchOptions) error {
+	return _global.Load().Watch(ctx, path, opts)
+}
+
+// RedirectStdLog redirects the output of the standard log package to module of
+// global instance.
+func RedirectStdLog(module string, lv zapcore.Level) func() {
+	return _global.Load().RedirectStdLog(module, lv)
+}
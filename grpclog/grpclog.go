@@ -0,0 +1,168 @@
+// Package grpclog implements a gRPC grpclog.LoggerV2 which writes through a
+// logzap module.
+package grpclog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kiraxie/logzap"
+	"go.uber.org/zap/zapcore"
+	ggrpclog "google.golang.org/grpc/grpclog"
+)
+
+var (
+	redirectMu sync.Mutex
+	// redirected is the logger installed by the last Redirect, nil for the
+	// default logger of gRPC
+	redirected ggrpclog.LoggerV2
+)
+
+// Redirect replaces the logger of gRPC with the logger of a module, such as
+// logzap.Get("grpc"), and return a function to restore the previous logger.
+// As grpclog.SetLoggerV2, it must be called before any gRPC function.
+//
+// gRPC can't report its logger, so the previous one is the logger of the
+// enclosing Redirect, otherwise the default logger of gRPC which honors
+// GRPC_GO_LOG_SEVERITY_LEVEL and GRPC_GO_LOG_VERBOSITY_LEVEL. A logger set by
+// grpclog.SetLoggerV2 directly is not restored.
+func Redirect(log logzap.Logger) func() {
+	redirectMu.Lock()
+	defer redirectMu.Unlock()
+	previous := redirected
+	redirected = NewLoggerV2(log)
+	ggrpclog.SetLoggerV2(redirected)
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			redirectMu.Lock()
+			defer redirectMu.Unlock()
+			redirected = previous
+			if previous == nil {
+				previous = defaultLoggerV2()
+			}
+			ggrpclog.SetLoggerV2(previous)
+		})
+	}
+}
+
+// defaultLoggerV2 return a logger as the default one of gRPC, which writes to
+// stderr by the severity and verbosity of environment.
+func defaultLoggerV2() ggrpclog.LoggerV2 {
+	infoW, warningW, errorW := io.Discard, io.Discard, io.Discard
+	switch strings.ToLower(os.Getenv("GRPC_GO_LOG_SEVERITY_LEVEL")) {
+	case "", "error":
+		errorW = os.Stderr
+	case "warning":
+		warningW = os.Stderr
+	case "info":
+		infoW = os.Stderr
+	}
+	v, _ := strconv.Atoi(os.Getenv("GRPC_GO_LOG_VERBOSITY_LEVEL"))
+
+	return ggrpclog.NewLoggerV2WithVerbosity(infoW, warningW, errorW, v)
+}
+
+// NewLoggerV2 return a grpclog.LoggerV2 which writes to the logger. The
+// verbosity V(l) is enabled if the zap level -l is, so the verbose logs of
+// gRPC are tuned by the module level as logrzap.
+func NewLoggerV2(log logzap.Logger) *LoggerV2 {
+	return &LoggerV2{log: log}
+}
+
+// LoggerV2 is a grpclog.DepthLoggerV2 backed by a logzap.Logger.
+type LoggerV2 struct {
+	log logzap.Logger
+}
+
+var _ ggrpclog.DepthLoggerV2 = (*LoggerV2)(nil)
+
+func (t *LoggerV2) Info(args ...interface{}) {
+	t.write(zapcore.InfoLevel, 0, fmt.Sprint(args...))
+}
+
+func (t *LoggerV2) Infoln(args ...interface{}) {
+	t.write(zapcore.InfoLevel, 0, sprintln(args))
+}
+
+func (t *LoggerV2) Warning(args ...interface{}) {
+	t.write(zapcore.WarnLevel, 0, fmt.Sprint(args...))
+}
+
+func (t *LoggerV2) Warningln(args ...interface{}) {
+	t.write(zapcore.WarnLevel, 0, sprintln(args))
+}
+
+func (t *LoggerV2) Error(args ...interface{}) {
+	t.write(zapcore.ErrorLevel, 0, fmt.Sprint(args...))
+}
+
+func (t *LoggerV2) Errorln(args ...interface{}) {
+	t.write(zapcore.ErrorLevel, 0, sprintln(args))
+}
+
+func (t *LoggerV2) Fatal(args ...interface{}) {
+	t.write(zapcore.FatalLevel, 0, fmt.Sprint(args...))
+}
+
+func (t *LoggerV2) Fatalln(args ...interface{}) {
+	t.write(zapcore.FatalLevel, 0, sprintln(args))
+}
+
+func (t *LoggerV2) Infof(format string, args ...interface{}) {
+	t.write(zapcore.InfoLevel, 0, fmt.Sprintf(format, args...))
+}
+
+func (t *LoggerV2) Warningf(format string, args ...interface{}) {
+	t.write(zapcore.WarnLevel, 0, fmt.Sprintf(format, args...))
+}
+
+func (t *LoggerV2) Errorf(format string, args ...interface{}) {
+	t.write(zapcore.ErrorLevel, 0, fmt.Sprintf(format, args...))
+}
+
+func (t *LoggerV2) Fatalf(format string, args ...interface{}) {
+	t.write(zapcore.FatalLevel, 0, fmt.Sprintf(format, args...))
+}
+
+func (t *LoggerV2) InfoDepth(depth int, args ...interface{}) {
+	t.write(zapcore.InfoLevel, depth, sprintln(args))
+}
+
+func (t *LoggerV2) WarningDepth(depth int, args ...interface{}) {
+	t.write(zapcore.WarnLevel, depth, sprintln(args))
+}
+
+func (t *LoggerV2) ErrorDepth(depth int, args ...interface{}) {
+	t.write(zapcore.ErrorLevel, depth, sprintln(args))
+}
+
+func (t *LoggerV2) FatalDepth(depth int, args ...interface{}) {
+	t.write(zapcore.FatalLevel, depth, sprintln(args))
+}
+
+func (t *LoggerV2) V(l int) bool {
+	return t.log.Enabled(zapcore.Level(-l))
+}
+
+// write logs the message with the caller depth frames above the caller of
+// LoggerV2, the fatal entries exit the process after written.
+func (t *LoggerV2) write(lv zapcore.Level, depth int, msg string) {
+	if ce := t.log.L().Check(lv, msg); ce != nil {
+		if ce.Caller.Defined {
+			ce.Caller = zapcore.NewEntryCaller(runtime.Caller(depth + 2))
+		}
+		ce.Write()
+	}
+}
+
+func sprintln(args []interface{}) string {
+	return strings.TrimSuffix(fmt.Sprintln(args...), "\n")
+}
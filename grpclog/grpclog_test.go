@@ -0,0 +1,78 @@
+package grpclog_test
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/kiraxie/logzap"
+	"github.com/kiraxie/logzap/grpclog"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	ggrpclog "google.golang.org/grpc/grpclog"
+)
+
+func helper(log *grpclog.LoggerV2) {
+	log.WarningDepth(1, "from", "helper")
+}
+
+func TestLoggerV2(t *testing.T) {
+	t.Parallel()
+	core, logs := observer.New(zapcore.Level(-2))
+	logger := logzap.Nop()
+	logger.Use(core)
+	logger.Reload(zapcore.InfoLevel, logzap.ModulesLevel{"grpc.transport": zapcore.WarnLevel})
+	log := grpclog.NewLoggerV2(logger.Get("grpc"))
+
+	log.Infof("dial %s", "a")
+	log.Infoln("dial", "b")
+	_, _, line, _ := runtime.Caller(0)
+	helper(log)
+	grpclog.NewLoggerV2(logger.Get("grpc.transport")).Info("dropped")
+	require.False(t, log.V(2))
+	logger.SetLevel("grpc", zapcore.Level(-2))
+	require.True(t, log.V(2))
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 3)
+	require.Equal(t, "dial a", entries[0].Message)
+	require.Equal(t, "dial b", entries[1].Message)
+	require.Equal(t, "from helper", entries[2].Message)
+	require.Equal(t, zapcore.WarnLevel, entries[2].Level)
+	require.Equal(t, line+1, entries[2].Caller.Line)
+	for _, e := range entries {
+		require.Equal(t, "grpc", e.LoggerName)
+		require.Equal(t, "grpclog_test.go", filepath.Base(e.Caller.File))
+	}
+}
+
+// TestRedirect must not run in parallel since it changes the logger of gRPC.
+func TestRedirect(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := logzap.Nop()
+	logger.Use(core)
+	undo := grpclog.Redirect(logger.Get("grpc"))
+	defer undo()
+
+	ggrpclog.Error("connection refused")
+	require.Len(t, logs.AllUntimed(), 1)
+	require.Equal(t, "connection refused", logs.AllUntimed()[0].Message)
+
+	// undo restores the logger of the enclosing Redirect
+	undoInner := grpclog.Redirect(logger.Get("grpc.inner"))
+	ggrpclog.Error("inner")
+	undoInner()
+	undoInner()
+	ggrpclog.Error("outer")
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 3)
+	require.Equal(t, "grpc.inner", entries[1].LoggerName)
+	require.Equal(t, "grpc", entries[2].LoggerName)
+	require.Equal(t, "outer", entries[2].Message)
+
+	// then the default logger of gRPC
+	undo()
+	ggrpclog.Info("default")
+	require.Len(t, logs.AllUntimed(), 3)
+}
@@ -0,0 +1,246 @@
+package grpclog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kiraxie/logzap"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// UnaryServerInterceptor logs each unary call to the submodule of the logger
+// named by the call, see MethodModule, and stores the logger of call in the
+// context of handler.
+func UnaryServerInterceptor(log logzap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		callLog := log.Named(MethodModule(info.FullMethod))
+		resp, err := handler(logzap.NewContext(ctx, callLog), req)
+		logCall(callLog, info.FullMethod, peerAddr(ctx), start, err,
+			zap.Int("bytes_received", size(req)),
+			zap.Int("bytes_sent", size(resp)),
+		)
+
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor logs each stream as UnaryServerInterceptor when the
+// handler returns, with the number and size of messages.
+func StreamServerInterceptor(log logzap.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		callLog := log.Named(MethodModule(info.FullMethod))
+		stream := &serverStream{ServerStream: ss, ctx: logzap.NewContext(ss.Context(), callLog)}
+		err := handler(srv, stream)
+		logCall(callLog, info.FullMethod, peerAddr(ss.Context()), start, err, stream.fields()...)
+
+		return err
+	}
+}
+
+// UnaryClientInterceptor logs each unary call as UnaryServerInterceptor, the
+// peer is the target of connection.
+func UnaryClientInterceptor(log logzap.Logger) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		fields := []zap.Field{zap.Int("bytes_sent", size(req))}
+		if err == nil {
+			fields = append(fields, zap.Int("bytes_received", size(reply)))
+		}
+		logCall(log.Named(MethodModule(method)), method, cc.Target(), start, err, fields...)
+
+		return err
+	}
+}
+
+// StreamClientInterceptor logs each stream as StreamServerInterceptor when the
+// stream ends, which is the first error of RecvMsg or SendMsg, or io.EOF.
+func StreamClientInterceptor(log logzap.Logger) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		callLog := log.Named(MethodModule(method))
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			logCall(callLog, method, cc.Target(), start, err)
+
+			return nil, err
+		}
+		stream := &clientStream{ClientStream: cs}
+		stream.done = func(err error) {
+			if errors.Is(err, io.EOF) {
+				err = nil
+			}
+			logCall(callLog, method, cc.Target(), start, err, stream.fields()...)
+		}
+
+		return stream, nil
+	}
+}
+
+// MethodModule return the module of a full method "/package.Service/Method",
+// which is "Service.Method" so the levels can be set by service or method,
+// e.g. "grpc.UserService" or "grpc.UserService.GetUser".
+func MethodModule(fullMethod string) string {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return fullMethod
+	}
+	if i := strings.LastIndexByte(service, '.'); i >= 0 {
+		service = service[i+1:]
+	}
+
+	return service + "." + method
+}
+
+// Level return the level of a call finished with code, Canceled and
+// DeadlineExceeded are WarnLevel since they are decided by the caller.
+func Level(code codes.Code) zapcore.Level {
+	switch code {
+	case codes.OK:
+		return zapcore.InfoLevel
+	case codes.Canceled, codes.DeadlineExceeded:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+func logCall(log logzap.Logger, method, peer string, start time.Time, err error, extra ...zap.Field) {
+	code := status.Code(err)
+	if ce := log.L().Check(Level(code), "rpc"); ce != nil {
+		fields := append([]zap.Field{
+			zap.String("method", method),
+			zap.String("peer", peer),
+			zap.String("code", code.String()),
+			zap.Duration("duration", time.Since(start)),
+		}, extra...)
+		if err != nil {
+			fields = append(fields, zap.Error(err))
+		}
+		ce.Write(fields...)
+	}
+}
+
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+
+	return ""
+}
+
+// size return the encoded size of a protobuf message, or 0 for others.
+func size(msg interface{}) int {
+	if m, ok := msg.(proto.Message); ok {
+		return proto.Size(m)
+	}
+
+	return 0
+}
+
+// counter counts the messages and bytes of a stream.
+type counter struct {
+	sent, received           int
+	bytesSent, bytesReceived int
+}
+
+func (t *counter) fields() []zap.Field {
+	return []zap.Field{
+		zap.Int("messages_sent", t.sent),
+		zap.Int("messages_received", t.received),
+		zap.Int("bytes_sent", t.bytesSent),
+		zap.Int("bytes_received", t.bytesReceived),
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	counter
+	ctx context.Context
+}
+
+func (t *serverStream) Context() context.Context {
+	return t.ctx
+}
+
+func (t *serverStream) SendMsg(m interface{}) error {
+	err := t.ServerStream.SendMsg(m)
+	if err == nil {
+		t.sent++
+		t.bytesSent += size(m)
+	}
+
+	return err
+}
+
+func (t *serverStream) RecvMsg(m interface{}) error {
+	err := t.ServerStream.RecvMsg(m)
+	if err == nil {
+		t.received++
+		t.bytesReceived += size(m)
+	}
+
+	return err
+}
+
+type clientStream struct {
+	grpc.ClientStream
+	mu sync.Mutex
+	counter
+	once sync.Once
+	done func(err error)
+}
+
+func (t *clientStream) SendMsg(m interface{}) error {
+	err := t.ClientStream.SendMsg(m)
+	t.mu.Lock()
+	if err == nil {
+		t.sent++
+		t.bytesSent += size(m)
+	}
+	t.mu.Unlock()
+	// io.EOF of SendMsg means the status is to be received by RecvMsg
+	if err != nil && !errors.Is(err, io.EOF) {
+		t.finish(err)
+	}
+
+	return err
+}
+
+func (t *clientStream) RecvMsg(m interface{}) error {
+	err := t.ClientStream.RecvMsg(m)
+	t.mu.Lock()
+	if err == nil {
+		t.received++
+		t.bytesReceived += size(m)
+	}
+	t.mu.Unlock()
+	if err != nil {
+		t.finish(err)
+	}
+
+	return err
+}
+
+func (t *clientStream) finish(err error) {
+	t.once.Do(func() { t.done(err) })
+}
+
+func (t *clientStream) fields() []zap.Field {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.counter.fields()
+}
@@ -0,0 +1,106 @@
+package grpclog_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/kiraxie/logzap"
+	"github.com/kiraxie/logzap/grpclog"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestMethodModule(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "UserService.GetUser", grpclog.MethodModule("/acme.user.v1.UserService/GetUser"))
+	require.Equal(t, "Health.Check", grpclog.MethodModule("/grpc.health.v1.Health/Check"))
+	require.Equal(t, "invalid", grpclog.MethodModule("invalid"))
+}
+
+func TestInterceptors(t *testing.T) {
+	t.Parallel()
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := logzap.Nop()
+	logger.Use(core)
+	logger.Reload(zapcore.InfoLevel, logzap.ModulesLevel{"client.Health.Watch": zapcore.ErrorLevel})
+
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			grpclog.UnaryServerInterceptor(logger.Get("server")),
+			func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				logzap.FromContext(ctx, "").Info("from handler")
+				return handler(ctx, req)
+			},
+		),
+		grpc.StreamInterceptor(grpclog.StreamServerInterceptor(logger.Get("server"))),
+	)
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	go func() { _ = server.Serve(lis) }()
+	defer server.Stop()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(grpclog.UnaryClientInterceptor(logger.Get("client"))),
+		grpc.WithStreamInterceptor(grpclog.StreamClientInterceptor(logger.Get("client"))),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "missing"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+	cancel()
+	_, err = stream.Recv()
+	require.Equal(t, codes.Canceled, status.Code(err))
+
+	require.Eventually(t, func() bool {
+		return byLogger(logs, "server.Health.Watch").Len() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.Equal(t, 2, byLogger(logs.FilterMessage("from handler"), "server.Health.Check").Len())
+	check := byLogger(logs.FilterMessage("rpc"), "server.Health.Check").AllUntimed()
+	require.Len(t, check, 2)
+	require.Equal(t, zapcore.InfoLevel, check[0].Level)
+	require.Equal(t, "OK", check[0].ContextMap()["code"])
+	require.Equal(t, "/grpc.health.v1.Health/Check", check[0].ContextMap()["method"])
+	require.Equal(t, int64(2), check[0].ContextMap()["bytes_sent"])
+	require.Equal(t, "bufconn", check[0].ContextMap()["peer"])
+	require.Equal(t, zapcore.ErrorLevel, check[1].Level)
+	require.Equal(t, "NotFound", check[1].ContextMap()["code"])
+
+	watch := byLogger(logs, "server.Health.Watch").AllUntimed()
+	require.Equal(t, zapcore.WarnLevel, watch[0].Level)
+	require.Equal(t, "Canceled", watch[0].ContextMap()["code"])
+	require.Equal(t, int64(1), watch[0].ContextMap()["messages_sent"])
+
+	clientCheck := byLogger(logs, "client.Health.Check").AllUntimed()
+	require.Len(t, clientCheck, 2)
+	require.Equal(t, "bufnet", clientCheck[0].ContextMap()["peer"])
+	require.Equal(t, int64(2), clientCheck[0].ContextMap()["bytes_received"])
+	// the canceled stream is warn, which is below the level of the module
+	require.Zero(t, byLogger(logs, "client.Health.Watch").Len())
+}
+
+func byLogger(logs *observer.ObservedLogs, name string) *observer.ObservedLogs {
+	return logs.Filter(func(e observer.LoggedEntry) bool { return e.LoggerName == name })
+}
@@ -0,0 +1,139 @@
+package logzap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var ErrInvalidRequest = errors.New("invalid request")
+
+// Handler return a http.Handler to inspect and change the levels at runtime.
+//
+// GET lists the global level and the effective level of all known modules.
+//
+// PUT and PATCH change the level of a module, or the global level if module
+// is empty, with a JSON body or a form:
+//
+//	{"module": "db", "level": "debug", "ttl": "10m"}
+//	module=db&level=debug&ttl=10m
+//
+// The optional ttl applies the level as a temporary override which is
+// reverted when it expires.
+func (t *Logzap) Handler() http.Handler {
+	return http.HandlerFunc(t.serveHTTP)
+}
+
+type levelsPayload struct {
+	Level     zapcore.Level              `json:"level"`
+	Modules   map[string]zapcore.Level   `json:"modules"`
+	Overrides map[string]overridePayload `json:"overrides,omitempty"`
+}
+
+type overridePayload struct {
+	Level   zapcore.Level `json:"level"`
+	Expires time.Time     `json:"expires"`
+}
+
+type levelRequest struct {
+	Module string         `json:"module"`
+	Level  *zapcore.Level `json:"level"`
+	TTL    string         `json:"ttl"`
+}
+
+type errorPayload struct {
+	Error string `json:"error"`
+}
+
+func (t *Logzap) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPatch:
+		req, err := decodeLevelRequest(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorPayload{Error: err.Error()})
+
+			return
+		}
+		if req.TTL == "" {
+			t.SetLevel(req.Module, *req.Level)
+			break
+		}
+		ttl, err := time.ParseDuration(req.TTL)
+		if err != nil || ttl <= 0 {
+			writeJSON(w, http.StatusBadRequest, errorPayload{Error: fmt.Sprintf("%s: ttl %q", ErrInvalidRequest, req.TTL)})
+
+			return
+		}
+		t.SetLevelFor(req.Module, *req.Level, ttl)
+	default:
+		w.Header().Set("Allow", strings.Join([]string{http.MethodGet, http.MethodPut, http.MethodPatch}, ", "))
+		writeJSON(w, http.StatusMethodNotAllowed, errorPayload{Error: fmt.Sprintf("method %s not allowed", r.Method)})
+
+		return
+	}
+	writeJSON(w, http.StatusOK, t.levels())
+}
+
+// levels return the global level, the effective level of all known modules and
+// the overrides.
+func (t *Logzap) levels() levelsPayload {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	p := levelsPayload{
+		Level:   t.globalLevel(),
+		Modules: map[string]zapcore.Level{},
+	}
+	t.modules.Range(func(_, value interface{}) bool {
+		m := value.(*module)
+		p.Modules[m.name] = m.Level()
+
+		return true
+	})
+	for name, o := range t.overrides {
+		if p.Overrides == nil {
+			p.Overrides = map[string]overridePayload{}
+		}
+		p.Overrides[name] = overridePayload{Level: o.level, Expires: o.expires}
+	}
+
+	return p
+}
+
+func decodeLevelRequest(r *http.Request) (req levelRequest, err error) {
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if contentType == "application/x-www-form-urlencoded" {
+		if err = r.ParseForm(); err != nil {
+			return req, fmt.Errorf("%w: %s", ErrInvalidRequest, err)
+		}
+		req.Module, req.TTL = r.Form.Get("module"), r.Form.Get("ttl")
+		if lv := r.Form.Get("level"); lv != "" {
+			req.Level = new(zapcore.Level)
+			if err = req.Level.UnmarshalText([]byte(lv)); err != nil {
+				return req, fmt.Errorf("%w: %s", ErrInvalidRequest, err)
+			}
+		}
+	} else if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, fmt.Errorf("%w: %s", ErrInvalidRequest, err)
+	}
+	if req.Module == "" {
+		req.Module = r.URL.Query().Get("module")
+	}
+	if req.Level == nil {
+		return req, fmt.Errorf("%w: level is required", ErrInvalidRequest)
+	}
+
+	return req, nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,94 @@
+package logzap_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kiraxie/logzap"
+	"github.com/kiraxie/logzap/core/loki"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+type levelsResponse struct {
+	Level     zapcore.Level            `json:"level"`
+	Modules   map[string]zapcore.Level `json:"modules"`
+	Overrides map[string]struct {
+		Level   zapcore.Level `json:"level"`
+		Expires time.Time     `json:"expires"`
+	} `json:"overrides"`
+	Error string `json:"error"`
+}
+
+func serveLevels(t *testing.T, h http.Handler, method, contentType, body string) (int, levelsResponse) {
+	t.Helper()
+	req := httptest.NewRequest(method, "/log/level", strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	resp := levelsResponse{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+
+	return rec.Code, resp
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+	logger := logzap.New(context.Background(), prometheus.NewRegistry(), logzap.Config{
+		Level:   zapcore.InfoLevel,
+		Modules: logzap.ModulesLevel{"db": zapcore.WarnLevel},
+	})
+	httpLogger := logger.Get("http")
+	h := logger.Handler()
+
+	code, resp := serveLevels(t, h, "GET", "", "")
+	require.Equal(t, 200, code)
+	require.Equal(t, zapcore.InfoLevel, resp.Level)
+	require.Equal(t, map[string]zapcore.Level{
+		"db":            zapcore.WarnLevel,
+		"http":          zapcore.InfoLevel,
+		loki.ModuleName: zapcore.InfoLevel,
+	}, resp.Modules)
+
+	code, resp = serveLevels(t, h, "PUT", "application/json", `{"module":"db","level":"debug"}`)
+	require.Equal(t, 200, code)
+	require.Equal(t, zapcore.DebugLevel, resp.Modules["db"])
+	require.True(t, logger.Get("db").Enabled(zapcore.DebugLevel))
+
+	code, resp = serveLevels(t, h, "PATCH", "application/x-www-form-urlencoded", url.Values{"level": {"error"}}.Encode())
+	require.Equal(t, 200, code)
+	require.Equal(t, zapcore.ErrorLevel, resp.Level)
+	require.Equal(t, zapcore.ErrorLevel, resp.Modules["http"])
+	require.Equal(t, zapcore.DebugLevel, resp.Modules["db"])
+	require.False(t, httpLogger.Enabled(zapcore.WarnLevel))
+
+	code, resp = serveLevels(t, h, "PUT", "application/json", `{"module":"http","level":"debug","ttl":"100ms"}`)
+	require.Equal(t, 200, code)
+	require.Equal(t, zapcore.DebugLevel, resp.Modules["http"])
+	require.Contains(t, resp.Overrides, "http")
+	require.True(t, httpLogger.Enabled(zapcore.DebugLevel))
+	require.Eventually(t, func() bool {
+		return !httpLogger.Enabled(zapcore.WarnLevel)
+	}, 5*time.Second, 10*time.Millisecond)
+	_, resp = serveLevels(t, h, "GET", "", "")
+	require.Empty(t, resp.Overrides)
+
+	code, resp = serveLevels(t, h, "PUT", "application/json", `{"module":"http"}`)
+	require.Equal(t, 400, code)
+	require.NotEmpty(t, resp.Error)
+	code, _ = serveLevels(t, h, "PUT", "application/json", `{"level":"verbose"}`)
+	require.Equal(t, 400, code)
+	code, _ = serveLevels(t, h, "PUT", "application/json", `{"level":"info","ttl":"soon"}`)
+	require.Equal(t, 400, code)
+	code, _ = serveLevels(t, h, "DELETE", "", "")
+	require.Equal(t, 405, code)
+}
@@ -0,0 +1,199 @@
+// Package hclogzap implements an hclog.Logger which writes through logzap
+// modules, the hclog names are the module names.
+package hclogzap
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"runtime"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/kiraxie/logzap"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Getter return the logger of module, such as logzap.Get or the Get method of
+// an instance.
+type Getter func(name string, opts ...zap.Option) logzap.Logger
+
+// New return an hclog.Logger which writes to the module name.
+func New(get Getter, name string) hclog.Logger {
+	return &logger{get: get, name: name, log: get(name)}
+}
+
+type logger struct {
+	get  Getter
+	name string
+	args []interface{}
+	log  logzap.Logger
+}
+
+// Level return the zap level of hclog level, Trace is the zap level below
+// DebugLevel and NoLevel is InfoLevel.
+func Level(lv hclog.Level) zapcore.Level {
+	switch lv {
+	case hclog.Trace:
+		return zapcore.DebugLevel - 1
+	case hclog.Debug:
+		return zapcore.DebugLevel
+	case hclog.Warn:
+		return zapcore.WarnLevel
+	case hclog.Error:
+		return zapcore.ErrorLevel
+	default:
+		return zapcore.InfoLevel
+	}
+}
+
+func (t *logger) Log(level hclog.Level, msg string, args ...interface{}) {
+	t.write(level, msg, args)
+}
+
+func (t *logger) Trace(msg string, args ...interface{}) { t.write(hclog.Trace, msg, args) }
+func (t *logger) Debug(msg string, args ...interface{}) { t.write(hclog.Debug, msg, args) }
+func (t *logger) Info(msg string, args ...interface{})  { t.write(hclog.Info, msg, args) }
+func (t *logger) Warn(msg string, args ...interface{})  { t.write(hclog.Warn, msg, args) }
+func (t *logger) Error(msg string, args ...interface{}) { t.write(hclog.Error, msg, args) }
+
+func (t *logger) IsTrace() bool { return t.log.Enabled(Level(hclog.Trace)) }
+func (t *logger) IsDebug() bool { return t.log.Enabled(Level(hclog.Debug)) }
+func (t *logger) IsInfo() bool  { return t.log.Enabled(Level(hclog.Info)) }
+func (t *logger) IsWarn() bool  { return t.log.Enabled(Level(hclog.Warn)) }
+func (t *logger) IsError() bool { return t.log.Enabled(Level(hclog.Error)) }
+
+func (t *logger) ImpliedArgs() []interface{} {
+	return t.args
+}
+
+func (t *logger) With(args ...interface{}) hclog.Logger {
+	return &logger{
+		get:  t.get,
+		name: t.name,
+		args: append(t.args[:len(t.args):len(t.args)], args...),
+		log:  t.log.With(fields(args)...),
+	}
+}
+
+func (t *logger) Name() string {
+	return t.name
+}
+
+func (t *logger) Named(name string) hclog.Logger {
+	if t.name != "" {
+		name = t.name + "." + name
+	}
+
+	return t.ResetNamed(name)
+}
+
+func (t *logger) ResetNamed(name string) hclog.Logger {
+	return &logger{get: t.get, name: name, args: t.args, log: t.get(name).With(fields(t.args)...)}
+}
+
+// SetLevel does nothing, the level is the one of module.
+func (t *logger) SetLevel(hclog.Level) {}
+
+// GetLevel return the most verbose level enabled by module.
+func (t *logger) GetLevel() hclog.Level {
+	for _, lv := range []hclog.Level{hclog.Trace, hclog.Debug, hclog.Info, hclog.Warn, hclog.Error} {
+		if t.log.Enabled(Level(lv)) {
+			return lv
+		}
+	}
+
+	return hclog.Off
+}
+
+func (t *logger) StandardLogger(opts *hclog.StandardLoggerOptions) *log.Logger {
+	return log.New(t.StandardWriter(opts), "", 0)
+}
+
+// StandardWriter return a writer which writes the lines at the forced level,
+// or the level inferred from the "[LEVEL]" prefix if InferLevels is set, or
+// InfoLevel.
+func (t *logger) StandardWriter(opts *hclog.StandardLoggerOptions) io.Writer {
+	if opts == nil {
+		opts = &hclog.StandardLoggerOptions{}
+	}
+
+	return &stdWriter{log: t, opts: *opts}
+}
+
+// write logs the message with the caller of logger.
+func (t *logger) write(level hclog.Level, msg string, args []interface{}) {
+	if level == hclog.Off {
+		return
+	}
+	if ce := t.log.L().Check(Level(level), msg); ce != nil {
+		if ce.Caller.Defined {
+			ce.Caller = zapcore.NewEntryCaller(runtime.Caller(2))
+		}
+		ce.Write(fields(args)...)
+	}
+}
+
+// fields converts the key-value pairs, a dangling value is kept with the key
+// "EXTRA_VALUE_AT_END" as hclog does.
+func fields(args []interface{}) []zap.Field {
+	fields := make([]zap.Field, 0, (len(args)+1)/2)
+	for i := 0; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			fields = append(fields, zap.Any(hclog.MissingKey, args[i]))
+			break
+		}
+		key, ok := args[i].(string)
+		if !ok {
+			key = fmt.Sprint(args[i])
+		}
+		if err, ok := args[i+1].(error); ok {
+			fields = append(fields, zap.NamedError(key, err))
+		} else {
+			fields = append(fields, zap.Any(key, args[i+1]))
+		}
+	}
+
+	return fields
+}
+
+type stdWriter struct {
+	log  *logger
+	opts hclog.StandardLoggerOptions
+}
+
+func (t *stdWriter) Write(p []byte) (int, error) {
+	msg := string(bytes.TrimRight(p, " \t\n"))
+	level := hclog.Info
+	switch {
+	case t.opts.ForceLevel != hclog.NoLevel:
+		level = t.opts.ForceLevel
+	case t.opts.InferLevels:
+		level, msg = inferLevel(msg)
+	}
+	if ce := t.log.log.L().Check(Level(level), msg); ce != nil {
+		ce.Caller = zapcore.EntryCaller{}
+		ce.Write()
+	}
+
+	return len(p), nil
+}
+
+func inferLevel(msg string) (hclog.Level, string) {
+	for prefix, lv := range map[string]hclog.Level{
+		"[TRACE]": hclog.Trace,
+		"[DEBUG]": hclog.Debug,
+		"[INFO]":  hclog.Info,
+		"[WARN]":  hclog.Warn,
+		"[ERR]":   hclog.Error,
+		"[ERROR]": hclog.Error,
+	} {
+		if strings.HasPrefix(msg, prefix) {
+			return lv, strings.TrimSpace(strings.TrimPrefix(msg, prefix))
+		}
+	}
+
+	return hclog.Info, msg
+}
@@ -0,0 +1,51 @@
+package hclogzap_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/kiraxie/logzap"
+	"github.com/kiraxie/logzap/hclogzap"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogger(t *testing.T) {
+	t.Parallel()
+	core, logs := observer.New(zapcore.Level(-2))
+	logger := logzap.Nop()
+	logger.Use(core)
+	logger.Reload(zapcore.InfoLevel, logzap.ModulesLevel{"vault.storage": zapcore.Level(-2)})
+	log := hclogzap.New(logger.Get, "vault")
+
+	log.Debug("dropped")
+	log.With("node", "a").Warn("sealed", "err", errors.New("eof"), "dangling")
+	storage := log.Named("storage")
+	storage.Trace("read", "path", "/kv")
+	storage.ResetNamed("audit").Error("denied")
+	log.StandardLogger(&hclog.StandardLoggerOptions{InferLevels: true}).Print("[ERR] standard")
+
+	require.Equal(t, "vault.storage", storage.Name())
+	require.Equal(t, hclog.Trace, storage.GetLevel())
+	require.Equal(t, hclog.Info, log.GetLevel())
+	require.False(t, log.IsDebug())
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 4)
+	require.Equal(t, "vault", entries[0].LoggerName)
+	require.Equal(t, []zap.Field{
+		zap.String("node", "a"),
+		zap.NamedError("err", errors.New("eof")),
+		zap.Any(hclog.MissingKey, "dangling"),
+	}, entries[0].Context)
+	require.Equal(t, "hclogzap_test.go", filepath.Base(entries[0].Caller.File))
+	require.Equal(t, "vault.storage", entries[1].LoggerName)
+	require.Equal(t, zapcore.Level(-2), entries[1].Level)
+	require.Equal(t, "audit", entries[2].LoggerName)
+	require.Equal(t, "standard", entries[3].Message)
+	require.Equal(t, zapcore.ErrorLevel, entries[3].Level)
+}
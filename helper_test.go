@@ -0,0 +1,141 @@
+package logzap_test
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/kiraxie/logzap"
+	"github.com/kiraxie/logzap/core/buffer"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap/zapcore"
+)
+
+// registerTestCore registers the constructor of scheme until the test ends,
+// the cores built by newCore are kept by the host of URL.
+func registerTestCore[T zapcore.Core](t *testing.T, scheme string, newCore func(u *url.URL) (T, error)) map[string]T {
+	t.Helper()
+	cores := map[string]T{}
+	logzap.RegisterCore(scheme, func(_ context.Context, _ prometheus.Registerer, rawURL string) (zapcore.Core, error) {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		core, err := newCore(u)
+		if err != nil {
+			return nil, err
+		}
+		cores[u.Host] = core
+
+		return core, nil
+	})
+	t.Cleanup(func() { logzap.UnregisterCore(scheme) })
+
+	return cores
+}
+
+// lockedBuffer is a buffer core safe for concurrent use.
+type lockedBuffer struct {
+	mu sync.Mutex
+	b  buffer.Buffer
+}
+
+func (t *lockedBuffer) Enabled(zapcore.Level) bool        { return true }
+func (t *lockedBuffer) With([]zapcore.Field) zapcore.Core { return t }
+func (t *lockedBuffer) Sync() error                       { return nil }
+func (t *lockedBuffer) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(ent, t)
+}
+
+func (t *lockedBuffer) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.b.Write(ent, fields)
+}
+
+func (t *lockedBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.b.String()
+}
+
+// syncBuffer is a buffer core counting its syncs.
+type syncBuffer struct {
+	buffer.Buffer
+	synced int
+}
+
+func (t *syncBuffer) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(ent, t)
+}
+
+func (t *syncBuffer) Sync() error {
+	t.synced++
+
+	return nil
+}
+
+// closeBuffer is a buffer core counting its flushes and closes, which fail
+// with err.
+type closeBuffer struct {
+	syncBuffer
+	flushed int
+	closed  int
+	err     error
+}
+
+func (t *closeBuffer) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(ent, t)
+}
+
+func (t *closeBuffer) Flush(context.Context) error {
+	t.flushed++
+
+	return t.err
+}
+
+func (t *closeBuffer) Close(context.Context) error {
+	t.closed++
+
+	return t.err
+}
+
+// failingCore is a core whose writes fail with ErrTest.
+type failingCore struct {
+	zapcore.LevelEnabler
+}
+
+func (t *failingCore) With([]zapcore.Field) zapcore.Core { return t }
+func (t *failingCore) Sync() error                       { return nil }
+func (t *failingCore) Write(zapcore.Entry, []zapcore.Field) error {
+	return ErrTest
+}
+
+func (t *failingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(ent, t)
+}
+
+// uncomparableCore is a core of a value type which panics when compared by ==.
+type uncomparableCore struct {
+	*syncBuffer
+	tags []string
+}
+
+// hookBuffer is a buffer core which calls onSync on every sync.
+type hookBuffer struct {
+	syncBuffer
+	onSync func()
+}
+
+func (t *hookBuffer) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(ent, t)
+}
+
+func (t *hookBuffer) Sync() error {
+	t.onSync()
+
+	return t.syncBuffer.Sync()
+}
@@ -0,0 +1,199 @@
+// Package httplog implements an access-log middleware of net/http which logs
+// to a logzap module.
+package httplog
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/kiraxie/logzap"
+	"github.com/kiraxie/logzap/filter"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultSkip is the health-check paths which are not logged by default.
+var DefaultSkip = []string{"/healthz", "/livez", "/readyz"}
+
+// Options configures the middleware, the zero value is ready to use.
+type Options struct {
+	// Route return the route of request, the URL path by default.
+	Route func(r *http.Request) string
+	// Levels overrides the level of requests by route, the key is a route or
+	// a pattern of path.Match such as "/api/*". By default a request is logged
+	// at InfoLevel, WarnLevel for 4xx and ErrorLevel for 5xx.
+	Levels map[string]zapcore.Level
+	// Skip is the routes which are not logged, DefaultSkip if nil.
+	Skip []string
+	// RequestIDHeader is the header of request ID, "X-Request-ID" by default.
+	// The ID is generated if the request carries none or an invalid one, and
+	// is returned in the same header of response.
+	RequestIDHeader string
+}
+
+// Middleware return a middleware which logs each request to the logger, and
+// stores the logger carrying the request ID in the request context, see
+// logzap.FromContext.
+func Middleware(log logzap.Logger, opts Options) func(http.Handler) http.Handler {
+	if opts.Route == nil {
+		opts.Route = func(r *http.Request) string { return r.URL.Path }
+	}
+	if opts.Skip == nil {
+		opts.Skip = DefaultSkip
+	}
+	if opts.RequestIDHeader == "" {
+		opts.RequestIDHeader = "X-Request-ID"
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := opts.Route(r)
+			if opts.skip(route) {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+			start := time.Now()
+			id := r.Header.Get(opts.RequestIDHeader)
+			if !validRequestID(id) {
+				id = newRequestID()
+			}
+			w.Header().Set(opts.RequestIDHeader, id)
+			reqLog := log.With(zap.String("request_id", id))
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sw, r.WithContext(logzap.NewContext(r.Context(), reqLog)))
+
+			if ce := reqLog.L().Check(opts.level(route, sw.status), "request"); ce != nil {
+				ce.Write(
+					zap.String("method", r.Method),
+					zap.String("route", route),
+					zap.String("url", filter.LogPattern(r.URL.String())),
+					zap.Int("status", sw.status),
+					zap.Int64("bytes", sw.bytes),
+					zap.Duration("duration", time.Since(start)),
+					zap.String("remote", r.RemoteAddr),
+				)
+			}
+		})
+	}
+}
+
+func (t *Options) skip(route string) bool {
+	for _, s := range t.Skip {
+		if s == route {
+			return true
+		}
+	}
+
+	return false
+}
+
+// level return the level of the exact route, or of the first matched pattern
+// in lexical order, or by the status.
+func (t *Options) level(route string, status int) zapcore.Level {
+	if lv, ok := t.Levels[route]; ok {
+		return lv
+	}
+	matched, found := "", false
+	for pattern := range t.Levels {
+		if ok, _ := path.Match(pattern, route); ok && (!found || pattern < matched) {
+			matched, found = pattern, true
+		}
+	}
+	switch {
+	case found:
+		return t.Levels[matched]
+	case status >= http.StatusInternalServerError:
+		return zapcore.ErrorLevel
+	case status >= http.StatusBadRequest:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.InfoLevel
+	}
+}
+
+// maxRequestIDLen is the longest request ID accepted from a request.
+const maxRequestIDLen = 128
+
+// validRequestID reports whether the request ID of a request is a bounded
+// token of letters, digits and "-_.:+/=", which covers UUIDs, trace IDs and
+// base64, so it is safe to log and echo.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		switch c := id[i]; {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.IndexByte("-_.:+/=", c) >= 0:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// statusWriter records the status and the size of response.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (t *statusWriter) WriteHeader(code int) {
+	if !t.wroteHeader {
+		t.status, t.wroteHeader = code, true
+	}
+	t.ResponseWriter.WriteHeader(code)
+}
+
+func (t *statusWriter) Write(b []byte) (int, error) {
+	t.wroteHeader = true
+	n, err := t.ResponseWriter.Write(b)
+	t.bytes += int64(n)
+
+	return n, err
+}
+
+func (t *statusWriter) Flush() {
+	if f, ok := t.ResponseWriter.(http.Flusher); ok {
+		t.wroteHeader = true
+		f.Flush()
+	}
+}
+
+// Hijack lets the handler take over the connection, such as for WebSocket,
+// the request is logged with status 101 unless a header was written.
+func (t *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := t.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %T", http.ErrNotSupported, t.ResponseWriter)
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil && !t.wroteHeader {
+		t.status, t.wroteHeader = http.StatusSwitchingProtocols, true
+	}
+
+	return conn, rw, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (t *statusWriter) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
@@ -0,0 +1,118 @@
+package httplog_test
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kiraxie/logzap"
+	"github.com/kiraxie/logzap/httplog"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := logzap.Nop()
+	logger.Use(core)
+	logger.SetLevel("", zapcore.DebugLevel)
+
+	handler := httplog.Middleware(logger.Get("http"), httplog.Options{
+		Levels: map[string]zapcore.Level{"/metrics": zapcore.DebugLevel, "/api/*": zapcore.WarnLevel},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logzap.FromContext(r.Context(), "handler").InfoContext(r.Context(), "handling")
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		default:
+			_, _ = w.Write([]byte("hello"))
+		}
+	}))
+	serve := func(target string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	rec := serve("/users?token=SECRET&page=2", http.Header{"X-Request-Id": {"req-1"}})
+	require.Equal(t, "req-1", rec.Header().Get("X-Request-ID"))
+	require.NotEmpty(t, serve("/missing", nil).Header().Get("X-Request-ID"))
+	serve("/metrics", nil)
+	serve("/api/users", nil)
+	serve("/healthz", nil)
+
+	entries := logs.FilterMessage("request").AllUntimed()
+	require.Len(t, entries, 4)
+	require.Equal(t, "http", entries[0].LoggerName)
+	require.Equal(t, zapcore.InfoLevel, entries[0].Level)
+	fields := entries[0].ContextMap()
+	require.Equal(t, "req-1", fields["request_id"])
+	require.Equal(t, "GET", fields["method"])
+	require.Equal(t, "/users", fields["route"])
+	require.Equal(t, "/users?token=[MASKED]&page=2", fields["url"])
+	require.Equal(t, int64(200), fields["status"])
+	require.Equal(t, int64(5), fields["bytes"])
+	require.Contains(t, fields, "duration")
+	require.Contains(t, fields, "remote")
+	require.Equal(t, zapcore.WarnLevel, entries[1].Level)
+	require.Equal(t, int64(404), entries[1].ContextMap()["status"])
+	require.Equal(t, zapcore.DebugLevel, entries[2].Level)
+	require.Equal(t, zapcore.WarnLevel, entries[3].Level)
+
+	handling := logs.FilterMessage("handling").AllUntimed()
+	require.Len(t, handling, 4)
+	require.Equal(t, "handler", handling[0].LoggerName)
+	require.Equal(t, "req-1", handling[0].ContextMap()["request_id"])
+
+	// the untrusted IDs are replaced
+	for _, id := range []string{"req 1", "req\x1b[31m", strings.Repeat("a", 129)} {
+		got := serve("/metrics", http.Header{"X-Request-Id": {id}}).Header().Get("X-Request-ID")
+		require.NotEqual(t, id, got)
+		require.Len(t, got, 16)
+	}
+}
+
+func TestMiddlewareHijack(t *testing.T) {
+	t.Parallel()
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := logzap.Nop()
+	logger.Use(core)
+	logger.SetLevel("", zapcore.DebugLevel)
+
+	server := httptest.NewServer(httplog.Middleware(logger.Get("http"), httplog.Options{})(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			conn, rw, err := http.NewResponseController(w).Hijack()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+
+				return
+			}
+			defer conn.Close()
+			_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: test\r\nConnection: Upgrade\r\n\r\n")
+			_ = rw.Flush()
+		})))
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: test\r\nUpgrade: test\r\nConnection: Upgrade\r\n\r\n"))
+	require.NoError(t, err)
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+	require.Eventually(t, func() bool { return logs.FilterMessage("request").Len() == 1 }, time.Second, 10*time.Millisecond)
+	require.Equal(t, int64(101), logs.FilterMessage("request").AllUntimed()[0].ContextMap()["status"])
+}
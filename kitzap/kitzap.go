@@ -0,0 +1,65 @@
+// Package kitzap implements a go-kit log.Logger which writes through a zap
+// logger, such as a logzap module.
+package kitzap
+
+import (
+	"fmt"
+
+	"github.com/go-kit/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Logger is the source of zap logger, which is implemented by logzap.Logger.
+// The logger is resolved on every entry so it follows the reloads.
+type Logger interface {
+	L() *zap.Logger
+}
+
+// New return a go-kit log.Logger which writes to log.
+//
+// The "level" key selects the level of entry, which is InfoLevel if absent,
+// the "msg" key is the message, and "ts" and "caller" are dropped in favor of
+// the ones of zap. The other pairs are written as fields.
+func New(log Logger) log.Logger {
+	return &logger{log: log}
+}
+
+type logger struct {
+	log Logger
+}
+
+func (t *logger) Log(keyvals ...interface{}) error {
+	lv, msg := zapcore.InfoLevel, ""
+	fields := make([]zap.Field, 0, len(keyvals)/2)
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		var value interface{} = log.ErrMissingValue
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+		switch key {
+		case "level":
+			if err := lv.UnmarshalText([]byte(fmt.Sprint(value))); err == nil {
+				continue
+			}
+		case "msg":
+			if msg == "" {
+				msg = fmt.Sprint(value)
+				continue
+			}
+		case "ts", "caller":
+			continue
+		}
+		if err, ok := value.(error); ok {
+			fields = append(fields, zap.NamedError(key, err))
+		} else {
+			fields = append(fields, zap.Any(key, value))
+		}
+	}
+	if ce := t.log.L().Check(lv, msg); ce != nil {
+		ce.Write(fields...)
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package kitzap_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/kiraxie/logzap"
+	"github.com/kiraxie/logzap/kitzap"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogger(t *testing.T) {
+	t.Parallel()
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := logzap.Nop()
+	logger.Use(core)
+	logger.Reload(zapcore.InfoLevel, logzap.ModulesLevel{"kit": zapcore.WarnLevel})
+	kit := log.With(kitzap.New(logger.Get("kit")), "ts", log.DefaultTimestampUTC, "caller", log.DefaultCaller)
+
+	require.NoError(t, level.Debug(kit).Log("msg", "debug"))
+	require.NoError(t, level.Info(kit).Log("msg", "info"))
+	require.NoError(t, level.Warn(kit).Log("msg", "warn", "host", "loki"))
+	require.NoError(t, level.Error(kit).Log("msg", "push failed", "err", errors.New("timeout"), "dangling"))
+	require.NoError(t, kit.Log("msg", "no level"))
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 2)
+	require.Equal(t, "kit", entries[0].LoggerName)
+	require.Equal(t, zapcore.WarnLevel, entries[0].Level)
+	require.Equal(t, []zap.Field{zap.String("host", "loki")}, entries[0].Context)
+	require.Equal(t, zapcore.ErrorLevel, entries[1].Level)
+	require.Equal(t, "push failed", entries[1].Message)
+	require.Equal(t, []zap.Field{
+		zap.NamedError("err", errors.New("timeout")),
+		zap.NamedError("dangling", log.ErrMissingValue),
+	}, entries[1].Context)
+}
@@ -0,0 +1,37 @@
+package logzap
+
+import (
+	"context"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Flusher is implemented by cores which buffer entries, Flush drains the
+// pending entries and keeps the core alive.
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// Closer is implemented by cores which own resources, Close drains the pending
+// entries and releases the resources, the core is unusable after that.
+type Closer interface {
+	Close(ctx context.Context) error
+}
+
+// flushCore flushes the core by Flusher if implemented, otherwise by Sync.
+func flushCore(ctx context.Context, core zapcore.Core) error {
+	if f, ok := core.(Flusher); ok {
+		return f.Flush(ctx)
+	}
+
+	return core.Sync()
+}
+
+// closeCore closes the core by Closer if implemented, otherwise flushes it.
+func closeCore(ctx context.Context, core zapcore.Core) error {
+	if c, ok := core.(Closer); ok {
+		return c.Close(ctx)
+	}
+
+	return flushCore(ctx, core)
+}
@@ -14,6 +14,7 @@ type Logger interface {
 	// TraceError logs a message at ErrorLevel if err is not nil.
 	TraceError(err error, fields ...zap.Field) error
 	// TraceContext logs a message at ErrorLevel if err is not nil and ctx is not done.
+	// The message includes the fields of ctx.
 	TraceContext(ctx context.Context, err error, fields ...zap.Field) error
 
 	// Error logs a message at ErrorLevel. The message includes any fields passed
@@ -25,6 +26,16 @@ type Logger interface {
 	// Debug logs a message at DebugLevel. The message includes any fields passed
 	Debug(msg string, fields ...zap.Field)
 
+	// ErrorContext logs a message at ErrorLevel with the fields of ctx, see
+	// RegisterContextExtractor.
+	ErrorContext(ctx context.Context, msg string, fields ...zap.Field)
+	// WarnContext logs a message at WarnLevel with the fields of ctx.
+	WarnContext(ctx context.Context, msg string, fields ...zap.Field)
+	// InfoContext logs a message at InfoLevel with the fields of ctx.
+	InfoContext(ctx context.Context, msg string, fields ...zap.Field)
+	// DebugContext logs a message at DebugLevel with the fields of ctx.
+	DebugContext(ctx context.Context, msg string, fields ...zap.Field)
+
 	// Errorf uses fmt.Sprintf to log a templated message at ErrorLevel.
 	Errorf(format string, args ...interface{})
 	// Warnf uses fmt.Sprintf to log a templated message at WarnLevel.
@@ -36,63 +47,126 @@ type Logger interface {
 
 	// Increase increase log level
 	Increase(lv zapcore.Level) Logger
+	// Named return the logger of submodule, which is registered as "parent.sub"
+	// and carries the fields of parent.
+	Named(sub string) Logger
+	// With return a logger carrying the fields, which still follows the level
+	// and cores of module.
+	With(fields ...zap.Field) Logger
 	// Level return
 	zapcore.LevelEnabler
 	// L return zap.Logger
 	L() *zap.Logger
 }
 
-func newLogger(log *zap.Logger, lv zapcore.Level, opts ...zap.Option) *logger {
-	l := &logger{
+func newModule(owner *Logzap, name string, lv zapcore.Level) *module {
+	return &module{
 		AtomicLevel: zap.NewAtomicLevelAt(lv),
-		opts:        opts,
+		owner:       owner,
+		name:        name,
 	}
-	l.instance.Store(log.WithOptions(append(opts, zap.IncreaseLevel(lv), zap.AddCallerSkip(1))...))
+}
+
+// module is the state shared by all loggers of a module name.
+type module struct {
+	zap.AtomicLevel
+	owner *Logzap
+	name  string
+	base  atomic.Pointer[zap.Logger]
+	// source and sampling are what base was built from, guarded by mu of owner
+	source   *zap.Logger
+	sampling Sampling
+	sampled  bool
+}
 
-	return l
+func (t *module) reload(log *zap.Logger, lv zapcore.Level) {
+	t.AtomicLevel.SetLevel(lv)
+	t.base.Store(log.WithOptions(withLevel(t.AtomicLevel), zap.AddCallerSkip(1)))
 }
 
+// logger is a handle of module, which may carry options, fields and an
+// increased level. The derived zap.Logger is rebuilt whenever the module is
+// reloaded, so the handle follows the level and cores of module.
 type logger struct {
-	zap.AtomicLevel
-	instance atomic.Pointer[zap.Logger]
+	*module
 	opts     []zap.Option
+	fields   []zap.Field
+	increase *zapcore.Level
+	derived  atomic.Pointer[derivedLogger]
+}
+
+type derivedLogger struct {
+	base, log *zap.Logger
 }
 
 func (t *logger) L() *zap.Logger {
-	return t.instance.Load()
+	base := t.base.Load()
+	if len(t.opts) == 0 && len(t.fields) == 0 && t.increase == nil {
+		return base
+	}
+	if d := t.derived.Load(); d != nil && d.base == base {
+		return d.log
+	}
+	log := base.WithOptions(t.opts...).With(t.fields...)
+	if t.increase != nil {
+		log = log.WithOptions(withLevel(*t.increase))
+	}
+	t.derived.Store(&derivedLogger{base: base, log: log})
+
+	return log
 }
 
-// reload doesn't change original options
-func (t *logger) reload(log *zap.Logger, lv zapcore.Level) {
-	t.AtomicLevel.SetLevel(lv)
-	t.instance.Store(log.WithOptions(append(t.opts, zap.IncreaseLevel(lv), zap.AddCallerSkip(1))...))
+func (t *logger) Enabled(lv zapcore.Level) bool {
+	return t.module.Enabled(lv) && (t.increase == nil || t.increase.Enabled(lv))
 }
 
 func (t *logger) Increase(lv zapcore.Level) Logger {
-	if !t.Level().Enabled(lv) {
+	if !t.Enabled(lv) {
 		return t
 	}
-	l := &logger{
-		AtomicLevel: zap.NewAtomicLevelAt(lv),
-		opts:        t.opts,
+
+	return &logger{module: t.module, opts: t.opts, fields: t.fields, increase: &lv}
+}
+
+func (t *logger) Named(sub string) Logger {
+	name := sub
+	if t.name != "" {
+		name = t.name + "." + sub
+	}
+	return &logger{module: t.owner.module(name), opts: t.opts, fields: t.fields, increase: t.increase}
+}
+
+// sibling return the logger of module from the same instance, which keeps the
+// fields, options and increased level of t.
+func (t *logger) sibling(module string) Logger {
+	return &logger{module: t.owner.module(module), opts: t.opts, fields: t.fields, increase: t.increase}
+}
+
+func (t *logger) With(fields ...zap.Field) Logger {
+	if len(fields) == 0 {
+		return t
 	}
-	l.instance.Store(t.instance.Load().WithOptions(zap.IncreaseLevel(lv)))
 
-	return l
+	return &logger{
+		module:   t.module,
+		opts:     t.opts,
+		fields:   append(t.fields[:len(t.fields):len(t.fields)], fields...),
+		increase: t.increase,
+	}
 }
 
 func (t *logger) Trace(err error, fields ...zap.Field) {
 	if err == nil {
 		return
 	}
-	t.instance.Load().Error(err.Error(), fields...)
+	t.L().Error(err.Error(), fields...)
 }
 
 func (t *logger) TraceError(err error, fields ...zap.Field) error {
 	if err == nil {
 		return nil
 	}
-	t.instance.Load().Error(err.Error(), fields...)
+	t.L().Error(err.Error(), fields...)
 
 	return err
 }
@@ -106,39 +180,72 @@ func (t *logger) TraceContext(ctx context.Context, err error, fields ...zap.Fiel
 		return nil
 	default:
 	}
-	t.instance.Load().Error(err.Error(), fields...)
+	if ce := t.L().Check(zapcore.ErrorLevel, err.Error()); ce != nil {
+		ce.Write(withContext(ctx, fields)...)
+	}
 
 	return err
 }
 
 func (t *logger) Error(msg string, fields ...zap.Field) {
-	t.instance.Load().Error(msg, fields...)
+	t.L().Error(msg, fields...)
 }
 
 func (t *logger) Warn(msg string, fields ...zap.Field) {
-	t.instance.Load().Warn(msg, fields...)
+	t.L().Warn(msg, fields...)
 }
 
 func (t *logger) Info(msg string, fields ...zap.Field) {
-	t.instance.Load().Info(msg, fields...)
+	t.L().Info(msg, fields...)
 }
 
 func (t *logger) Debug(msg string, fields ...zap.Field) {
-	t.instance.Load().Debug(msg, fields...)
+	t.L().Debug(msg, fields...)
+}
+
+func (t *logger) ErrorContext(ctx context.Context, msg string, fields ...zap.Field) {
+	if ce := t.L().Check(zapcore.ErrorLevel, msg); ce != nil {
+		ce.Write(withContext(ctx, fields)...)
+	}
+}
+
+func (t *logger) WarnContext(ctx context.Context, msg string, fields ...zap.Field) {
+	if ce := t.L().Check(zapcore.WarnLevel, msg); ce != nil {
+		ce.Write(withContext(ctx, fields)...)
+	}
+}
+
+func (t *logger) InfoContext(ctx context.Context, msg string, fields ...zap.Field) {
+	if ce := t.L().Check(zapcore.InfoLevel, msg); ce != nil {
+		ce.Write(withContext(ctx, fields)...)
+	}
+}
+
+func (t *logger) DebugContext(ctx context.Context, msg string, fields ...zap.Field) {
+	if ce := t.L().Check(zapcore.DebugLevel, msg); ce != nil {
+		ce.Write(withContext(ctx, fields)...)
+	}
+}
+
+// withContext merges the fields of ctx and the given fields, which win on the
+// same key. It is called after the entry is checked so the extractors only run
+// for the enabled entries.
+func withContext(ctx context.Context, fields []zap.Field) []zap.Field {
+	return mergeFields(ContextFields(ctx), fields)
 }
 
 func (t *logger) Errorf(format string, args ...interface{}) {
-	t.instance.Load().Sugar().Errorf(format, args...)
+	t.L().Sugar().Errorf(format, args...)
 }
 
 func (t *logger) Warnf(format string, args ...interface{}) {
-	t.instance.Load().Sugar().Warnf(format, args...)
+	t.L().Sugar().Warnf(format, args...)
 }
 
 func (t *logger) Infof(format string, args ...interface{}) {
-	t.instance.Load().Sugar().Infof(format, args...)
+	t.L().Sugar().Infof(format, args...)
 }
 
 func (t *logger) Debugf(format string, args ...interface{}) {
-	t.instance.Load().Sugar().Debugf(format, args...)
+	t.L().Sugar().Debugf(format, args...)
 }
@@ -0,0 +1,127 @@
+// Package logruszap bridges logrus into logzap modules, so the logrus output
+// follows the module levels and cores.
+package logruszap
+
+import (
+	"io"
+	"sort"
+
+	"github.com/kiraxie/logzap"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ModuleKey is the field of logrus entry which selects the submodule, e.g.
+// logrus.WithField(logruszap.ModuleKey, "raft") writes to "parent.raft".
+const ModuleKey = "module"
+
+// New return a logrus.Logger which writes to the logger only.
+func New(log logzap.Logger) *logrus.Logger {
+	l := logrus.New()
+	Bridge(l, log)
+
+	return l
+}
+
+// Bridge forwards all entries of l to the logger, and discards the output of
+// l. The level of l is opened to TraceLevel so the module level decides.
+func Bridge(l *logrus.Logger, log logzap.Logger) {
+	l.SetLevel(logrus.TraceLevel)
+	l.SetOutput(io.Discard)
+	l.SetFormatter(Formatter{})
+	l.AddHook(NewHook(log))
+}
+
+// Formatter formats nothing, it saves the formatting of entries which are
+// written by Hook only.
+type Formatter struct{}
+
+func (Formatter) Format(*logrus.Entry) ([]byte, error) { return nil, nil }
+
+// Hook is a logrus.Hook which writes the entries to a logzap.Logger.
+type Hook struct {
+	log logzap.Logger
+}
+
+// NewHook return a logrus.Hook which writes to the logger.
+func NewHook(log logzap.Logger) *Hook {
+	return &Hook{log: log}
+}
+
+func (t *Hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Level return the zap level of logrus level, TraceLevel is the zap level
+// below DebugLevel.
+func Level(lv logrus.Level) zapcore.Level {
+	switch lv {
+	case logrus.PanicLevel:
+		return zapcore.PanicLevel
+	case logrus.FatalLevel:
+		return zapcore.FatalLevel
+	case logrus.ErrorLevel:
+		return zapcore.ErrorLevel
+	case logrus.WarnLevel:
+		return zapcore.WarnLevel
+	case logrus.InfoLevel:
+		return zapcore.InfoLevel
+	case logrus.DebugLevel:
+		return zapcore.DebugLevel
+	default:
+		return zapcore.DebugLevel - 1
+	}
+}
+
+// Fire writes the entry with its time, caller and fields. The panic and fatal
+// entries are written without the terminal behavior of zap, which is left to
+// logrus.
+func (t *Hook) Fire(entry *logrus.Entry) error {
+	log := t.log
+	keys := make([]string, 0, len(entry.Data))
+	for k, v := range entry.Data {
+		if module, ok := v.(string); ok && k == ModuleKey {
+			log = log.Named(module)
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	lv := Level(entry.Level)
+	zl := log.L()
+	if lv > zapcore.ErrorLevel {
+		zl = zl.WithOptions(zap.WithFatalHook(zapcore.WriteThenPanic))
+		defer func() {
+			if r := recover(); r != nil && r != entry.Message {
+				panic(r)
+			}
+		}()
+	}
+	ce := zl.Check(lv, entry.Message)
+	if ce == nil {
+		return nil
+	}
+	ce.Time = entry.Time
+	ce.Caller = zapcore.EntryCaller{}
+	if entry.Caller != nil {
+		ce.Caller = zapcore.EntryCaller{
+			Defined:  true,
+			PC:       entry.Caller.PC,
+			File:     entry.Caller.File,
+			Line:     entry.Caller.Line,
+			Function: entry.Caller.Function,
+		}
+	}
+	fields := make([]zap.Field, 0, len(keys))
+	for _, k := range keys {
+		if err, ok := entry.Data[k].(error); ok {
+			fields = append(fields, zap.NamedError(k, err))
+		} else {
+			fields = append(fields, zap.Any(k, entry.Data[k]))
+		}
+	}
+	ce.Write(fields...)
+
+	return nil
+}
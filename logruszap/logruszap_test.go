@@ -0,0 +1,40 @@
+package logruszap_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kiraxie/logzap"
+	"github.com/kiraxie/logzap/logruszap"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestHook(t *testing.T) {
+	t.Parallel()
+	core, logs := observer.New(zapcore.Level(-2))
+	logger := logzap.Nop()
+	logger.Use(core)
+	logger.Reload(zapcore.InfoLevel, logzap.ModulesLevel{"legacy.raft": zapcore.Level(-2)})
+	l := logruszap.New(logger.Get("legacy"))
+
+	l.Debug("dropped")
+	l.WithError(errors.New("eof")).WithField("peer", "a").Warn("lost peer")
+	l.WithField(logruszap.ModuleKey, "raft").Trace("heartbeat")
+	require.Panics(t, func() {
+		l.WithField("n", 1).Panic("boom")
+	})
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 3)
+	require.Equal(t, "lost peer", entries[0].Message)
+	require.Equal(t, zapcore.WarnLevel, entries[0].Level)
+	require.Equal(t, []zap.Field{zap.NamedError("error", errors.New("eof")), zap.String("peer", "a")}, entries[0].Context)
+	require.Equal(t, "legacy.raft", entries[1].LoggerName)
+	require.Equal(t, zapcore.Level(-2), entries[1].Level)
+	require.Empty(t, entries[1].Context)
+	require.Equal(t, zapcore.PanicLevel, entries[2].Level)
+	require.Equal(t, "legacy", entries[2].LoggerName)
+}
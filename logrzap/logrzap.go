@@ -0,0 +1,114 @@
+// Package logrzap implements a go-logr LogSink which writes through logzap
+// modules, so the noisy controllers can be tuned by the module levels.
+package logrzap
+
+import (
+	"math"
+	"runtime"
+
+	"github.com/go-logr/logr"
+	"github.com/kiraxie/logzap"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// New return a logr.Logger which writes to the logger.
+func New(log logzap.Logger) logr.Logger {
+	return logr.New(NewLogSink(log))
+}
+
+// NewLogSink return a logr.LogSink which writes to the logger.
+func NewLogSink(log logzap.Logger) *LogSink {
+	return &LogSink{log: log}
+}
+
+// LogSink is a logr.LogSink backed by a logzap.Logger.
+//
+// WithName opens the submodule "parent.name", and the V-level n is written at
+// zapcore.Level(-n), so V(1) is DebugLevel and V(2) is enabled by the module
+// level -2 only.
+type LogSink struct {
+	log       logzap.Logger
+	callDepth int
+}
+
+var (
+	_ logr.LogSink          = (*LogSink)(nil)
+	_ logr.CallDepthLogSink = (*LogSink)(nil)
+)
+
+// Level return the zap level of a logr V-level.
+func Level(v int) zapcore.Level {
+	if v > -math.MinInt8 {
+		return math.MinInt8
+	}
+
+	return zapcore.Level(-v)
+}
+
+func (t *LogSink) Init(info logr.RuntimeInfo) {
+	t.callDepth += info.CallDepth
+}
+
+func (t *LogSink) Enabled(level int) bool {
+	return t.log.Enabled(Level(level))
+}
+
+func (t *LogSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	if ce := t.log.L().Check(Level(level), msg); ce != nil {
+		t.write(ce, keysAndValues)
+	}
+}
+
+func (t *LogSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	if ce := t.log.L().Check(zapcore.ErrorLevel, msg); ce != nil {
+		t.write(ce, keysAndValues, zap.Error(err))
+	}
+}
+
+func (t *LogSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &LogSink{log: t.log.With(fields(keysAndValues)...), callDepth: t.callDepth}
+}
+
+func (t *LogSink) WithName(name string) logr.LogSink {
+	return &LogSink{log: t.log.Named(name), callDepth: t.callDepth}
+}
+
+func (t *LogSink) WithCallDepth(depth int) logr.LogSink {
+	return &LogSink{log: t.log, callDepth: t.callDepth + depth}
+}
+
+// write replaces the caller found by zap, which is the logr.Logger, with the
+// caller of it.
+func (t *LogSink) write(ce *zapcore.CheckedEntry, keysAndValues []interface{}, extra ...zap.Field) {
+	if ce.Caller.Defined {
+		ce.Caller = zapcore.NewEntryCaller(runtime.Caller(t.callDepth + 2))
+	}
+	ce.Write(append(extra, fields(keysAndValues)...)...)
+}
+
+// fields converts the key-value pairs, a key which is not a string and a
+// dangling value are kept with the key "!BADKEY" as log/slog does.
+func fields(keysAndValues []interface{}) []zap.Field {
+	fields := make([]zap.Field, 0, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); {
+		key, ok := keysAndValues[i].(string)
+		if !ok || i+1 == len(keysAndValues) {
+			fields = append(fields, zap.Any("!BADKEY", keysAndValues[i]))
+			i++
+			continue
+		}
+		fields = append(fields, anyField(key, keysAndValues[i+1]))
+		i += 2
+	}
+
+	return fields
+}
+
+func anyField(key string, value interface{}) zap.Field {
+	if m, ok := value.(logr.Marshaler); ok {
+		value = m.MarshalLog()
+	}
+
+	return zap.Any(key, value)
+}
@@ -0,0 +1,48 @@
+package logrzap_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/kiraxie/logzap"
+	"github.com/kiraxie/logzap/logrzap"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogSink(t *testing.T) {
+	t.Parallel()
+	core, logs := observer.New(zapcore.Level(-3))
+	logger := logzap.Nop()
+	logger.Use(core)
+	logger.Reload(zapcore.InfoLevel, logzap.ModulesLevel{
+		"operator.reconciler": zapcore.Level(-2),
+	})
+	log := logrzap.New(logger.Get("operator"))
+
+	log.V(1).Info("debug")
+	log.Info("info", "n", 1, "dangling")
+	rec := log.WithName("reconciler").WithValues("kind", "Pod")
+	rec.V(1).Info("v1")
+	rec.V(2).Info("v2")
+	rec.V(3).Info("v3")
+	rec.Error(errors.New("failed"), "reconcile", "name", "foo")
+	require.True(t, rec.V(2).Enabled())
+	require.False(t, log.V(1).Enabled())
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 4)
+	require.Equal(t, "info", entries[0].Message)
+	require.Equal(t, []zap.Field{zap.Int("n", 1), zap.Any("!BADKEY", "dangling")}, entries[0].Context)
+	require.Equal(t, "logrzap_test.go", filepath.Base(entries[0].Caller.File))
+	require.Equal(t, "operator.reconciler", entries[1].LoggerName)
+	require.Equal(t, zapcore.DebugLevel, entries[1].Level)
+	require.Equal(t, zapcore.Level(-2), entries[2].Level)
+	require.Equal(t, "v2", entries[2].Message)
+	require.Equal(t, zapcore.ErrorLevel, entries[3].Level)
+	require.Equal(t, map[string]interface{}{"kind": "Pod", "error": "failed", "name": "foo"}, entries[3].ContextMap())
+	require.Equal(t, "logrzap_test.go", filepath.Base(entries[3].Caller.File))
+}
@@ -2,8 +2,14 @@ package logzap
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/kiraxie/logzap/core/loki"
+	"github.com/kiraxie/logzap/kitzap"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/multierr"
 	"go.uber.org/zap"
@@ -12,9 +18,15 @@ import (
 
 // Nop return a instance which does nothing
 func Nop() *Logzap {
+	// the collectors never conflict in a new registry
+	m, _ := newMetrics(prometheus.NewRegistry())
+
 	return &Logzap{
-		log:     zap.NewNop(),
-		modules: map[string]*logger{},
+		ctx:      context.Background(),
+		registry: prometheus.NewRegistry(),
+		metrics:  m,
+		log:      zap.NewNop(),
+		cores:    map[string]zapcore.Core{},
 	}
 }
 
@@ -50,51 +62,68 @@ func NewE(
 	if registry == nil {
 		registry = prometheus.DefaultRegisterer
 	}
-	if len(c.Cores) == 0 {
-		c.Cores = Cores{"console": "console://"}
-	}
-	t = &Logzap{
-		level: c.Level,
-	}
-	cores, err := c.Cores.Build(ctx, registry)
+	m, err := newMetrics(registry)
 	if err != nil {
 		return nil, err
 	}
-	for _, c := range cores {
-		t.syncs = append(t.syncs, c.Sync)
+	t = &Logzap{
+		ctx:      ctx,
+		registry: registry,
+		metrics:  m,
+		log:      zap.NewNop(),
 	}
-	if t.log, err = newZapLogger(cores); err != nil {
+	if err = t.ReloadConfig(ctx, c); err != nil {
 		return nil, err
 	}
-	t.modules = c.Modules.build(t.log)
 
 	return t, nil
 }
 
-func newZapLogger(cores []zapcore.Core) (*zap.Logger, error) {
+func (t *Logzap) newZapLogger(cores []zapcore.Core) (*zap.Logger, error) {
 
 	return zap.New(
 		zapcore.NewTee(cores...),
 		zap.Development(),
 		zap.AddStacktrace(zap.WarnLevel),
 		zap.AddCaller(),
+		zap.Hooks(t.metrics.hook),
 	), nil
 }
 
 type Logzap struct {
-	mu      sync.RWMutex
+	mu       sync.RWMutex
+	ctx      context.Context
+	registry prometheus.Registerer
+	metrics  *metrics
+	config   Config
+	log      *zap.Logger
+	cores    map[string]zapcore.Core
+	// generation counts the swaps of cores, a reload built on an earlier one
+	// is built again
+	generation uint64
+	// modules is the registry of *module keyed by name, the lookups are
+	// lock-free and the creation is serialized by mu
+	modules   sync.Map
+	overrides map[string]*override
+	// rules is the configured modules overlaid with the overrides
+	rules ModulesLevel
+}
+
+// override is a temporary level of module, the empty module is the global level.
+type override struct {
 	level   zapcore.Level
-	log     *zap.Logger
-	modules map[string]*logger
-	syncs   []func() error
+	expires time.Time
+	timer   *time.Timer
 }
 
+// Sync syncs all cores, which may log through the instance meanwhile.
 func (t *Logzap) Sync() (err error) {
 	t.mu.RLock()
-	defer t.mu.RUnlock()
+	cores := t.cores
+	t.mu.RUnlock()
 
-	for _, sync := range t.syncs {
-		if e := sync(); e != nil {
+	for _, core := range cores {
+		if e := core.Sync(); e != nil {
 			err = multierr.Append(err, e)
 		}
 	}
@@ -102,44 +131,342 @@ func (t *Logzap) Sync() (err error) {
 	return
 }
 
-func (t *Logzap) Get(name string, opts ...zap.Option) Logger {
+// Config return the configuration currently applied.
+func (t *Logzap) Config() Config {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
-	l, ok := t.modules[name]
-	if !ok {
-		l = newLogger(t.log.Named(name), t.level, opts...)
-		t.modules[name] = l
-	} else if len(opts) > 0 {
-		l.opts = opts
-		l.reload(t.log.Named(name), l.Level())
+
+	return t.config
+}
+
+// Flush drains the pending entries of all cores and keeps them alive.
+func (t *Logzap) Flush(ctx context.Context) (err error) {
+	t.mu.RLock()
+	cores := t.cores
+	t.mu.RUnlock()
+
+	for _, name := range coreNames(cores) {
+		if e := flushCore(ctx, cores[name]); e != nil {
+			err = multierr.Append(err, fmt.Errorf("core %q: %w", name, e))
+		}
+	}
+
+	return
+}
+
+// Close detaches all cores from the loggers and closes them within the
+// deadline of ctx. The instance discards entries until the next ReloadConfig.
+func (t *Logzap) Close(ctx context.Context) (err error) {
+	t.mu.Lock()
+	cores := t.cores
+	t.cores = map[string]zapcore.Core{}
+	t.generation++
+	t.log = zap.NewNop()
+	t.reloadModules()
+	t.mu.Unlock()
+
+	for _, name := range coreNames(cores) {
+		if e := closeCore(ctx, cores[name]); e != nil {
+			err = multierr.Append(err, fmt.Errorf("core %q: %w", name, e))
+		}
 	}
 
-	return l
+	return
+}
+
+// Get return a logger of module, the options apply to the returned logger and
+// the loggers derived from it only.
+func (t *Logzap) Get(name string, opts ...zap.Option) Logger {
+	return &logger{module: t.module(name), opts: opts}
+}
+
+// module return the registered module or registers a new one.
+func (t *Logzap) module(name string) *module {
+	if m, ok := t.modules.Load(name); ok {
+		return m.(*module)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.loadOrCreateModule(name)
+}
+
+// loadOrCreateModule must be called with mu held.
+func (t *Logzap) loadOrCreateModule(name string) *module {
+	if m, ok := t.modules.Load(name); ok {
+		return m.(*module)
+	}
+	m := newModule(t, name, t.moduleLevel(name))
+	t.reloadModule(m, m.Level())
+	t.modules.Store(name, m)
+
+	return m
 }
 
 // only reconfiguration the global level and submodule level
 func (t *Logzap) Reload(lv zapcore.Level, modules ModulesLevel) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	t.level = lv
+	t.config.Level = lv
+	t.config.Modules = modules.normalize()
+	t.reloadModules()
+}
+
+// ReloadConfig reconfigures the instance with the given configuration.
+//
+// The cores whose URL changed are built before anything is swapped, if any of
+// them fails the new cores are closed and the running logger is left untouched.
+// Otherwise all cached loggers are switched to the new cores and levels, then
+// the removed cores are closed. The cores are built without blocking the
+// loggers, and built again if another reload or Close swapped the cores
+// meanwhile. The new cores live with the context of NewE, ctx only bounds the
+// reload and the closing.
+func (t *Logzap) ReloadConfig(ctx context.Context, c Config) (err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if len(c.Cores) == 0 {
+		c.Cores = Cores{"console": "console://"}
+	}
+	c.Modules = c.Modules.normalize()
+	c.Sampling = c.Sampling.normalize()
+	coreCtx := t.coreContext()
+	var (
+		cores map[string]zapcore.Core
+		log   *zap.Logger
+	)
+	for {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		t.mu.RLock()
+		generation, running, urls := t.generation, t.cores, t.config.Cores
+		t.mu.RUnlock()
+
+		var created []zapcore.Core
+		cores, created, err = t.buildCores(coreCtx, running, urls, c.Cores)
+		if err == nil {
+			log, err = t.newZapLogger(sortedCores(cores))
+		}
+		if err == nil {
+			t.mu.Lock()
+			if t.generation == generation {
+				break
+			}
+			t.mu.Unlock()
+		}
+		for _, core := range created {
+			_ = closeCore(ctx, core)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	// mu is held since the generation was checked
+	removed := map[string]zapcore.Core{}
+	for name, core := range t.cores {
+		if url, ok := c.Cores[name]; !ok || url != t.config.Cores[name] {
+			removed[name] = core
+		}
+	}
+	t.config, t.log, t.cores = c, log, cores
+	t.generation++
+	t.metrics.estimateBytes.Store(c.Metrics.Bytes)
+	t.reloadModules()
+	t.mu.Unlock()
+
+	for _, name := range coreNames(removed) {
+		if e := closeCore(ctx, removed[name]); e != nil {
+			err = multierr.Append(err, fmt.Errorf("core %q: %w", name, e))
+		}
+	}
+
+	return
+}
+
+// SetLevel changes the level of module, or the global level if module is empty.
+// The change lasts until the next Reload or ReloadConfig.
+func (t *Logzap) SetLevel(module string, lv zapcore.Level) {
+	module = strings.ToLower(module)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if module == "" {
+		t.config.Level = lv
+	} else {
+		modules := make(ModulesLevel, len(t.config.Modules)+1)
+		for k, v := range t.config.Modules {
+			modules[k] = v
+		}
+		modules[module] = lv
+		t.config.Modules = modules
+	}
+	t.reloadModules()
+}
 
-	for name, m := range t.modules {
-		// reset the submodules to new general level which not in new Modules configuration
-		if _, ok := modules[name]; !ok {
-			m.reload(t.log.Named(name), t.level)
-		} else {
-			t.modules[name] = newLogger(t.log.Named(name), lv)
+// SetLevelFor overrides the level of module, or the global level if module is
+// empty, for the duration of ttl. The override takes precedence over the
+// configuration and survives reloads until it expires.
+func (t *Logzap) SetLevelFor(module string, lv zapcore.Level, ttl time.Duration) {
+	module = strings.ToLower(module)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.overrides == nil {
+		t.overrides = map[string]*override{}
+	}
+	if o, ok := t.overrides[module]; ok {
+		o.timer.Stop()
+	}
+	o := &override{level: lv, expires: time.Now().Add(ttl)}
+	o.timer = time.AfterFunc(ttl, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.overrides[module] == o {
+			delete(t.overrides, module)
+			t.reloadModules()
 		}
+	})
+	t.overrides[module] = o
+	t.reloadModules()
+}
+
+// buildCores builds the cores whose URL differs from urls of the running
+// cores and reuses the others, the created cores are returned even if error
+// occurred.
+func (t *Logzap) buildCores(
+	ctx context.Context,
+	running map[string]zapcore.Core,
+	urls Cores,
+	c Cores,
+) (cores map[string]zapcore.Core, created []zapcore.Core, err error) {
+	cores = make(map[string]zapcore.Core, len(c))
+	for _, name := range c.names() {
+		if core, ok := running[name]; ok && urls[name] == c[name] {
+			cores[name] = core
+			continue
+		}
+		core, err := c.BuildByName(ctx, t.registry, name)
+		if err != nil {
+			return nil, created, err
+		}
+		core = &metricsCore{Core: core, name: name, metrics: t.metrics}
+		cores[name] = core
+		created = append(created, core)
 	}
+
+	return
 }
 
+// coreContext return the context of the cores, which carries the loggers of
+// their internal logs. It must be called without mu held since the modules
+// are created.
+func (t *Logzap) coreContext() context.Context {
+	return loki.WithLogger(t.ctx, kitzap.New(t.Get(loki.ModuleName)))
+}
+
+// Use replaces all cores with the given core until the next ReloadConfig.
 func (t *Logzap) Use(core zapcore.Core) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	t.log = t.log.WithOptions(zap.WrapCore(func(zapcore.Core) zapcore.Core {
-		return core
+	t.log, _ = t.newZapLogger([]zapcore.Core{core})
+	t.modules.Range(func(_, value interface{}) bool {
+		m := value.(*module)
+		t.reloadModule(m, m.Level())
+
+		return true
+	})
+}
+
+// reloadModules re-evaluates the level rules and applies the current logger and
+// levels to all cached loggers, the modules in configuration are created if
+// absent.
+func (t *Logzap) reloadModules() {
+	t.rules = make(ModulesLevel, len(t.config.Modules)+len(t.overrides))
+	for name, lv := range t.config.Modules {
+		t.rules[name] = lv
+	}
+	for name, o := range t.overrides {
+		if name != "" {
+			t.rules[name] = o.level
+		}
+	}
+	for name := range t.config.Modules {
+		if !isGlob(name) {
+			t.loadOrCreateModule(name)
+		}
+	}
+	t.modules.Range(func(_, value interface{}) bool {
+		m := value.(*module)
+		t.reloadModule(m, t.moduleLevel(m.name))
+
+		return true
+	})
+}
+
+// reloadModule applies the current logger and lv to module. The logger of
+// module is rebuilt only if the cores or the sampling of module changed, so
+// its sampler keeps counting across the level changes.
+func (t *Logzap) reloadModule(m *module, lv zapcore.Level) {
+	sampling, sampled := t.config.Sampling.Lookup(m.name)
+	if m.source == t.log && m.sampled == sampled && m.sampling == sampling {
+		m.SetLevel(lv)
+
+		return
+	}
+	m.source, m.sampling, m.sampled = t.log, sampling, sampled
+	m.reload(t.moduleLogger(m.name, sampling, sampled), lv)
+}
+
+// moduleLogger return the logger of module, which is wrapped by a sampler if
+// sampled.
+func (t *Logzap) moduleLogger(name string, sampling Sampling, sampled bool) *zap.Logger {
+	log := t.log.Named(name)
+	if !sampled {
+		return log
+	}
+	tick := sampling.Tick
+	if tick <= 0 {
+		tick = time.Second
+	}
+
+	return log.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewSamplerWithOptions(core, tick, sampling.Initial, sampling.Thereafter,
+			zapcore.SamplerHook(t.metrics.sampled))
 	}))
-	for name, m := range t.modules {
-		m.reload(t.log.Named(name), m.Level())
+}
+
+// moduleLevel return the level of the most specific rule of module, or the
+// global level.
+func (t *Logzap) moduleLevel(name string) zapcore.Level {
+	if lv, ok := t.rules.Lookup(name); ok {
+		return lv
+	}
+
+	return t.globalLevel()
+}
+
+func (t *Logzap) globalLevel() zapcore.Level {
+	if o, ok := t.overrides[""]; ok {
+		return o.level
+	}
+
+	return t.config.Level
+}
+
+func coreNames(cores map[string]zapcore.Core) []string {
+	names := make([]string, 0, len(cores))
+	for name := range cores {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+
+	return names
+}
+
+func sortedCores(cores map[string]zapcore.Core) []zapcore.Core {
+	result := make([]zapcore.Core, 0, len(cores))
+	for _, name := range coreNames(cores) {
+		result = append(result, cores[name])
+	}
+
+	return result
 }
@@ -4,14 +4,22 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/kiraxie/logzap"
 	"github.com/kiraxie/logzap/core/buffer"
+	"github.com/kiraxie/logzap/core/loki"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 var (
@@ -95,3 +103,297 @@ func TestLogzap(t *testing.T) {
 		assert.Contains(t, b.String(), `Get "https://google.com?foo=boo&token=[MASKED]": unexpected EOF`)
 	}
 }
+
+func TestReloadConfig(t *testing.T) {
+	t.Parallel()
+	buffers := registerTestCore(t, "test-reload", func(u *url.URL) (*syncBuffer, error) {
+		if u.Host == "fail" {
+			return nil, ErrTest
+		}
+
+		return &syncBuffer{}, nil
+	})
+
+	logger, err := logzap.NewE(context.Background(), prometheus.NewRegistry(), logzap.Config{
+		Level:   zapcore.InfoLevel,
+		Modules: logzap.ModulesLevel{"foo": zapcore.WarnLevel},
+		Cores:   logzap.Cores{"a": "test-reload://a", "b": "test-reload://b"},
+	})
+	require.NoError(t, err)
+	foo := logger.Get("foo")
+	boo := logger.Get("boo")
+	foo.Info("foo-info")
+	boo.Info("boo-info")
+	require.NotContains(t, buffers["a"].String(), "foo-info")
+	require.Contains(t, buffers["a"].String(), "boo-info")
+
+	require.NoError(t, logger.ReloadConfig(context.Background(), logzap.Config{
+		Level:   zapcore.WarnLevel,
+		Modules: logzap.ModulesLevel{"FOO": zapcore.DebugLevel},
+		Cores:   logzap.Cores{"b": "test-reload://b", "c": "test-reload://c"},
+	}))
+	require.Equal(t, 1, buffers["a"].synced)
+	require.Zero(t, buffers["b"].synced)
+	foo.Debug("foo-debug")
+	boo.Info("boo-info-2")
+	require.NotContains(t, buffers["a"].String(), "foo-debug")
+	require.Contains(t, buffers["b"].String(), "foo-debug")
+	require.Contains(t, buffers["c"].String(), "foo-debug")
+	require.NotContains(t, buffers["c"].String(), "boo-info-2")
+
+	c := buffers["c"]
+	require.ErrorIs(t, logger.ReloadConfig(context.Background(), logzap.Config{
+		Level: zapcore.DebugLevel,
+		Cores: logzap.Cores{"b": "test-reload://b", "d": "test-reload://d", "e": "test-reload://fail"},
+	}), ErrTest)
+	require.Equal(t, 1, buffers["d"].synced)
+	require.Zero(t, c.synced)
+	boo.Info("boo-info-3")
+	foo.Debug("foo-debug-2")
+	require.NotContains(t, c.String(), "boo-info-3")
+	require.Contains(t, c.String(), "foo-debug-2")
+	require.NotContains(t, buffers["d"].String(), "foo-debug-2")
+}
+
+func TestReloadConfigUncomparableCore(t *testing.T) {
+	t.Parallel()
+	buffers := registerTestCore(t, "test-uncomparable", func(*url.URL) (uncomparableCore, error) {
+		return uncomparableCore{syncBuffer: &syncBuffer{}, tags: []string{"a"}}, nil
+	})
+
+	logger, err := logzap.NewE(context.Background(), prometheus.NewRegistry(), logzap.Config{
+		Cores: logzap.Cores{"a": "test-uncomparable://a"},
+	})
+	require.NoError(t, err)
+	// the kept and removed cores are told apart by URL, the core itself may
+	// not be comparable
+	require.NotPanics(t, func() {
+		require.NoError(t, logger.ReloadConfig(context.Background(), logzap.Config{
+			Cores: logzap.Cores{"a": "test-uncomparable://a", "b": "test-uncomparable://b"},
+		}))
+	})
+	require.Zero(t, buffers["a"].synced)
+	require.NotPanics(t, func() {
+		require.NoError(t, logger.ReloadConfig(context.Background(), logzap.Config{
+			Cores: logzap.Cores{"b": "test-uncomparable://b"},
+		}))
+	})
+	require.Equal(t, 1, buffers["a"].synced)
+	require.Zero(t, buffers["b"].synced)
+}
+
+func TestClose(t *testing.T) {
+	t.Parallel()
+	buffers := registerTestCore(t, "test-close", func(u *url.URL) (*closeBuffer, error) {
+		if u.Host == "fail" {
+			return &closeBuffer{err: ErrTest}, nil
+		}
+
+		return &closeBuffer{}, nil
+	})
+
+	logger, err := logzap.NewE(context.Background(), prometheus.NewRegistry(), logzap.Config{
+		Level: zapcore.InfoLevel,
+		Cores: logzap.Cores{"ok": "test-close://ok", "fail": "test-close://fail?level=warn"},
+	})
+	require.NoError(t, err)
+	foo := logger.Get("foo")
+	foo.Warn("before close")
+
+	require.NoError(t, logger.Sync())
+	err = logger.Flush(context.Background())
+	require.ErrorIs(t, err, ErrTest)
+	require.Contains(t, err.Error(), `core "fail"`)
+	require.Equal(t, 1, buffers["ok"].flushed)
+	require.Equal(t, 1, buffers["ok"].synced)
+	require.Zero(t, buffers["ok"].closed)
+
+	err = logger.Close(context.Background())
+	require.ErrorIs(t, err, ErrTest)
+	require.Contains(t, err.Error(), `core "fail"`)
+	require.Equal(t, 1, buffers["ok"].closed)
+	require.Equal(t, 1, buffers["fail"].closed)
+
+	foo.Warn("after close")
+	require.Contains(t, buffers["ok"].String(), "before close")
+	require.NotContains(t, buffers["ok"].String(), "after close")
+}
+
+func TestReloadConfigConcurrent(t *testing.T) {
+	t.Parallel()
+	var (
+		logger *logzap.Logzap
+		outer  []*closeBuffer
+	)
+	buffers := registerTestCore(t, "test-reload-concurrent", func(u *url.URL) (*closeBuffer, error) {
+		core := &closeBuffer{}
+		if u.Host != "outer" {
+			return core, nil
+		}
+		outer = append(outer, core)
+		// the cores are built without the lock, so loggers and another reload
+		// run meanwhile
+		logger.Get("building").Info("building")
+		if len(outer) == 1 {
+			require.NoError(t, logger.ReloadConfig(context.Background(), logzap.Config{
+				Cores: logzap.Cores{"inner": "test-reload-concurrent://inner"},
+			}))
+		}
+
+		return core, nil
+	})
+
+	logger = logzap.New(context.Background(), prometheus.NewRegistry(), logzap.Config{
+		Cores: logzap.Cores{"buffer": "buffer://"},
+	})
+	require.NoError(t, logger.ReloadConfig(context.Background(), logzap.Config{
+		Cores: logzap.Cores{"outer": "test-reload-concurrent://outer"},
+	}))
+
+	// the reload is built again on the cores swapped meanwhile
+	require.Len(t, outer, 2)
+	require.Equal(t, 1, outer[0].closed)
+	require.Zero(t, outer[1].closed)
+	require.Equal(t, 1, buffers["inner"].closed)
+	require.Equal(t, logzap.Cores{"outer": "test-reload-concurrent://outer"}, logger.Config().Cores)
+	logger.Get("foo").Info("after reload")
+	require.Contains(t, outer[1].String(), "after reload")
+}
+
+func TestSyncLogs(t *testing.T) {
+	t.Parallel()
+	var logger *logzap.Logzap
+	registerTestCore(t, "test-sync-logs", func(*url.URL) (*hookBuffer, error) {
+		return &hookBuffer{onSync: func() { logger.Get("sync").Info("synced") }}, nil
+	})
+	logger = logzap.New(context.Background(), prometheus.NewRegistry(), logzap.Config{
+		Cores: logzap.Cores{"hook": "test-sync-logs://hook"},
+	})
+
+	// a core may log to a new module while it is synced
+	done := make(chan error)
+	go func() { done <- logger.Sync() }()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "Sync blocked by logging")
+	}
+}
+
+func TestLokiRejected(t *testing.T) {
+	t.Parallel()
+	var pushes atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		pushes.Add(1)
+		http.Error(w, "bad request", http.StatusBadRequest)
+	}))
+	defer server.Close()
+	buffers := registerTestCore(t, "test-loki-rejected", func(*url.URL) (*lockedBuffer, error) {
+		return &lockedBuffer{}, nil
+	})
+
+	logger := logzap.New(context.Background(), prometheus.NewRegistry(), logzap.Config{
+		Cores: logzap.Cores{
+			"loki":   "loki+" + server.URL + "/loki/api/v1/push?label.job=test",
+			"errors": "test-loki-rejected://errors?modules=" + loki.ModuleName,
+		},
+	})
+	logger.Get("app").Info("rejected")
+
+	done := make(chan error)
+	go func() {
+		err := logger.Sync()
+		if err == nil {
+			err = logger.Flush(context.Background())
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "Sync and Flush blocked by the rejected push")
+	}
+	require.Equal(t, int32(1), pushes.Load())
+	// the rejection is logged by the module of loki, which is never shipped
+	require.Eventually(t, func() bool {
+		return buffers["errors"].String() != ""
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, logger.Close(context.Background()))
+}
+
+func TestModuleInheritance(t *testing.T) {
+	t.Parallel()
+	logger := logzap.New(context.Background(), prometheus.NewRegistry(), logzap.Config{
+		Level: zapcore.WarnLevel,
+		Modules: logzap.ModulesLevel{
+			"parent.child": zapcore.InfoLevel,
+			"db.*":         zapcore.DebugLevel,
+		},
+	})
+	grandchild := logger.Get("parent.child.grandchild")
+	pool := logger.Get("db.pool")
+	db := logger.Get("db")
+	require.True(t, grandchild.Enabled(zapcore.InfoLevel))
+	require.False(t, grandchild.Enabled(zapcore.DebugLevel))
+	require.True(t, pool.Enabled(zapcore.DebugLevel))
+	require.False(t, db.Enabled(zapcore.InfoLevel))
+
+	logger.Reload(zapcore.InfoLevel, logzap.ModulesLevel{
+		"parent": zapcore.ErrorLevel,
+		"db":     zapcore.DebugLevel,
+	})
+	require.False(t, grandchild.Enabled(zapcore.WarnLevel))
+	require.True(t, grandchild.Enabled(zapcore.ErrorLevel))
+	require.True(t, pool.Enabled(zapcore.DebugLevel))
+	require.True(t, db.Enabled(zapcore.DebugLevel))
+}
+
+func TestNamedWith(t *testing.T) {
+	t.Parallel()
+	logger := logzap.New(context.Background(), prometheus.NewRegistry(), logzap.Config{
+		Level:   zapcore.InfoLevel,
+		Modules: logzap.ModulesLevel{"parent.sub": zapcore.WarnLevel},
+	})
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger.Use(core)
+
+	parent := logger.Get("parent").With(zap.String("request", "1"))
+	sub := parent.Named("sub").With(zap.Int("n", 1))
+	sub.Info("sub-info")
+	sub.Warn("sub-warn")
+	parent.Debug("parent-debug")
+	parent.Info("parent-info")
+	entries := logs.TakeAll()
+	require.Len(t, entries, 2)
+	require.Equal(t, "parent.sub", entries[0].LoggerName)
+	require.Equal(t, "sub-warn", entries[0].Message)
+	require.Equal(t, map[string]interface{}{"request": "1", "n": int64(1)}, entries[0].ContextMap())
+	require.Equal(t, "parent-info", entries[1].Message)
+	require.Equal(t, map[string]interface{}{"request": "1"}, entries[1].ContextMap())
+
+	logger.Reload(zapcore.DebugLevel, logzap.ModulesLevel{"parent": zapcore.ErrorLevel})
+	parent.Warn("parent-warn")
+	sub.Debug("sub-debug")
+	logger.Get("parent.sub").Debug("registered")
+
+	core2, logs2 := observer.New(zapcore.DebugLevel)
+	logger.Use(core2)
+	sub.Error("sub-error")
+	parent.Error("parent-error")
+	require.Zero(t, logs.Len())
+	entries = logs2.TakeAll()
+	require.Len(t, entries, 2)
+	require.Equal(t, "sub-error", entries[0].Message)
+	require.Equal(t, map[string]interface{}{"request": "1", "n": int64(1)}, entries[0].ContextMap())
+	require.Equal(t, "parent-error", entries[1].Message)
+
+	increased := sub.Increase(zapcore.ErrorLevel)
+	require.False(t, increased.Enabled(zapcore.WarnLevel))
+	logger.Reload(zapcore.DebugLevel, nil)
+	increased.Warn("increased-warn")
+	increased.Error("increased-error")
+	require.Equal(t, 1, logs2.FilterMessage("increased-error").Len())
+	require.Zero(t, logs2.FilterMessage("increased-warn").Len())
+}
@@ -0,0 +1,208 @@
+package logzap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type metrics struct {
+	entries      *prometheus.CounterVec
+	bytes        *prometheus.CounterVec
+	writeErrors  *prometheus.CounterVec
+	writeLatency *prometheus.HistogramVec
+	sampling     *prometheus.CounterVec
+	encoder      zapcore.Encoder
+	// estimateBytes enables the bytes, which encodes every entry again
+	estimateBytes atomic.Bool
+}
+
+func newMetrics(registry prometheus.Registerer) (m *metrics, err error) {
+	m = &metrics{encoder: zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())}
+	if m.entries, err = registerOrGet(registry, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "logzap",
+		Name:      "entries_total",
+		Help:      "Number of log entries written by module and level.",
+	}, []string{"module", "level"})); err != nil {
+		return nil, err
+	}
+	if m.bytes, err = registerOrGet(registry, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "logzap",
+		Name:      "bytes_total",
+		Help:      "Estimated number of bytes of log entries written by module and core, measured in JSON encoding.",
+	}, []string{"module", "core"})); err != nil {
+		return nil, err
+	}
+	if m.writeErrors, err = registerOrGet(registry, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "logzap",
+		Name:      "core_write_errors_total",
+		Help:      "Number of failed writes by core.",
+	}, []string{"core"})); err != nil {
+		return nil, err
+	}
+	if m.writeLatency, err = registerOrGet(registry, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "logzap",
+		Name:      "core_write_duration_seconds",
+		Help:      "Duration of writes by core.",
+		Buckets:   prometheus.ExponentialBuckets(1e-6, 4, 10),
+	}, []string{"core"})); err != nil {
+		return nil, err
+	}
+	if m.sampling, err = registerOrGet(registry, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "logzap",
+		Name:      "sampled_entries_total",
+		Help:      "Number of log entries sampled or dropped by the sampling of module.",
+	}, []string{"module", "decision"})); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// registerOrGet registers the collector or return the registered one, so that
+// instances can share the same registry. An error is returned if the collector
+// conflicts with a registered one, or the registered one is of another type.
+func registerOrGet[T prometheus.Collector](registry prometheus.Registerer, c T) (T, error) {
+	if err := registry.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			return c, err
+		}
+		existing, ok := are.ExistingCollector.(T)
+		if !ok {
+			return c, fmt.Errorf("%w: registered as %T", err, are.ExistingCollector)
+		}
+
+		return existing, nil
+	}
+
+	return c, nil
+}
+
+// hook counts the entries written to at least one core.
+func (t *metrics) hook(ent zapcore.Entry) error {
+	t.entries.WithLabelValues(ent.LoggerName, ent.Level.String()).Inc()
+
+	return nil
+}
+
+// sampled counts the decisions of the module samplers.
+func (t *metrics) sampled(ent zapcore.Entry, dec zapcore.SamplingDecision) {
+	decision := "sampled"
+	if dec&zapcore.LogDropped != 0 {
+		decision = "dropped"
+	}
+	t.sampling.WithLabelValues(ent.LoggerName, decision).Inc()
+}
+
+func (t *metrics) observe(core string, ent zapcore.Entry, fields []zapcore.Field, d time.Duration, failed bool) {
+	t.writeLatency.WithLabelValues(core).Observe(d.Seconds())
+	if failed {
+		t.writeErrors.WithLabelValues(core).Inc()
+
+		return
+	}
+	if !t.estimateBytes.Load() {
+		return
+	}
+	// the size is estimated by JSON since the encoder of core is unknown
+	if buf, e := t.encoder.EncodeEntry(ent, fields); e == nil {
+		t.bytes.WithLabelValues(ent.LoggerName, core).Add(float64(buf.Len()))
+		buf.Free()
+	}
+}
+
+// metricsCore instruments the writes of a named core.
+type metricsCore struct {
+	zapcore.Core
+	name    string
+	metrics *metrics
+}
+
+func (t *metricsCore) With(fields []zapcore.Field) zapcore.Core {
+	return &metricsCore{Core: t.Core.With(fields), name: t.name, metrics: t.metrics}
+}
+
+// Check lets the wrapped core decide on its own, and writes the entry through
+// the decision of it so the filtering of wrapped core is kept.
+func (t *metricsCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	downstream := t.Core.Check(ent, nil)
+	if downstream == nil {
+		return ce
+	}
+	w := _checkedWritePool.Get().(*checkedWrite)
+	w.core, w.downstream = t, downstream
+	w.outer = ce.AddCore(ent, w)
+
+	return w.outer
+}
+
+func (t *metricsCore) Flush(ctx context.Context) error {
+	return flushCore(ctx, t.Core)
+}
+
+func (t *metricsCore) Close(ctx context.Context) error {
+	return closeCore(ctx, t.Core)
+}
+
+var _checkedWritePool = sync.Pool{New: func() interface{} { return &checkedWrite{} }}
+
+// checkedWrite writes an entry accepted by the wrapped core of metricsCore.
+type checkedWrite struct {
+	core       *metricsCore
+	downstream *zapcore.CheckedEntry
+	// outer is the entry which carries the write, its ErrorOutput is set by
+	// the logger after the check
+	outer  *zapcore.CheckedEntry
+	output errorOutput
+}
+
+func (t *checkedWrite) Enabled(zapcore.Level) bool        { return true }
+func (t *checkedWrite) With([]zapcore.Field) zapcore.Core { return t }
+func (t *checkedWrite) Sync() error                       { return nil }
+func (t *checkedWrite) Check(_ zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce
+}
+
+func (t *checkedWrite) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	start := time.Now()
+	t.output = errorOutput{out: t.outer.ErrorOutput}
+	t.downstream.ErrorOutput = &t.output
+	t.downstream.Write(fields...)
+	t.core.metrics.observe(t.core.name, ent, fields, time.Since(start), t.output.failed)
+	*t = checkedWrite{}
+	_checkedWritePool.Put(t)
+
+	return nil
+}
+
+// errorOutput forwards the write errors reported by a CheckedEntry to the
+// error output of logger and records that the write failed.
+type errorOutput struct {
+	out    zapcore.WriteSyncer
+	failed bool
+}
+
+func (t *errorOutput) Write(p []byte) (int, error) {
+	t.failed = true
+	if t.out == nil {
+		return len(p), nil
+	}
+
+	return t.out.Write(p)
+}
+
+func (t *errorOutput) Sync() error {
+	if t.out == nil {
+		return nil
+	}
+
+	return t.out.Sync()
+}
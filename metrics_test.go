@@ -0,0 +1,166 @@
+package logzap_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kiraxie/logzap"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+	registerTestCore(t, "test-metrics", func(u *url.URL) (zapcore.Core, error) {
+		if u.Host == "fail" {
+			return &failingCore{LevelEnabler: zapcore.DebugLevel}, nil
+		}
+
+		return &lockedBuffer{}, nil
+	})
+
+	registry := prometheus.NewRegistry()
+	logger := logzap.New(context.Background(), registry, logzap.Config{
+		Level: zapcore.InfoLevel,
+		Cores: logzap.Cores{
+			"ok":   "test-metrics://ok",
+			"fail": "test-metrics://fail?modules=db",
+		},
+		Metrics: logzap.MetricsConfig{Bytes: true},
+	})
+	var errorOutput zaptest.Buffer
+	logger.Get("db", zap.ErrorOutput(&errorOutput)).Info("db-info")
+	logger.Get("db").Debug("db-debug")
+	logger.Get("http").Warn("http-warn")
+	logger.Get("http").Warn("http-warn")
+
+	require.NoError(t, testutil.CollectAndCompare(registry, strings.NewReader(`
+# HELP logzap_entries_total Number of log entries written by module and level.
+# TYPE logzap_entries_total counter
+logzap_entries_total{level="info",module="db"} 1
+logzap_entries_total{level="warn",module="http"} 2
+# HELP logzap_core_write_errors_total Number of failed writes by core.
+# TYPE logzap_core_write_errors_total counter
+logzap_core_write_errors_total{core="fail"} 1
+`), "logzap_entries_total", "logzap_core_write_errors_total"))
+
+	// the failure is still reported to the error output of logger once
+	require.Len(t, errorOutput.Lines(), 1)
+	require.Contains(t, errorOutput.String(), ErrTest.Error())
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	written := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() != "logzap_bytes_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			written[labels["module"]+"/"+labels["core"]] = m.GetCounter().GetValue()
+		}
+	}
+	require.NotZero(t, written["db/ok"])
+	require.NotZero(t, written["http/ok"])
+	require.NotContains(t, written, "db/fail")
+	require.Equal(t, 2, testutil.CollectAndCount(registry, "logzap_core_write_duration_seconds"))
+
+	// instances share the collectors of registry
+	_ = logzap.New(context.Background(), registry, logzap.Config{})
+
+	// the bytes are not estimated by default
+	registry = prometheus.NewRegistry()
+	logger = logzap.New(context.Background(), registry, logzap.Config{
+		Cores: logzap.Cores{"ok": "test-metrics://ok"},
+	})
+	logger.Get("db").Info("db-info")
+	require.Zero(t, testutil.CollectAndCount(registry, "logzap_bytes_total"))
+}
+
+func TestMetricsConflict(t *testing.T) {
+	t.Parallel()
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Namespace: "logzap", Name: "entries_total"}))
+	_, err := logzap.NewE(context.Background(), registry, logzap.Config{Level: zapcore.InfoLevel})
+	require.Error(t, err)
+
+	registry = prometheus.NewRegistry()
+	registry.MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "logzap",
+		Name:      "entries_total",
+		Help:      "Other help.",
+	}, []string{"module", "level"}))
+	_, err = logzap.NewE(context.Background(), registry, logzap.Config{Level: zapcore.InfoLevel})
+	require.Error(t, err)
+
+	registry = prometheus.NewRegistry()
+	_, err = logzap.NewE(context.Background(), registry, logzap.Config{Level: zapcore.InfoLevel})
+	require.NoError(t, err)
+	_, err = logzap.NewE(context.Background(), registry, logzap.Config{Level: zapcore.InfoLevel})
+	require.NoError(t, err)
+}
+
+func TestSampling(t *testing.T) {
+	t.Parallel()
+	registry := prometheus.NewRegistry()
+	logger := logzap.New(context.Background(), registry, logzap.Config{
+		Level: zapcore.InfoLevel,
+		Cores: logzap.Cores{"buffer": "buffer://"},
+		Sampling: logzap.ModulesSampling{
+			"db": {Initial: 2, Thereafter: 3, Tick: time.Minute},
+		},
+	})
+	for i := 0; i < 8; i++ {
+		logger.Get("db.query").Info("query")
+		logger.Get("http").Info("request")
+	}
+
+	require.NoError(t, testutil.CollectAndCompare(registry, strings.NewReader(`
+# HELP logzap_entries_total Number of log entries written by module and level.
+# TYPE logzap_entries_total counter
+logzap_entries_total{level="info",module="db.query"} 4
+logzap_entries_total{level="info",module="http"} 8
+# HELP logzap_sampled_entries_total Number of log entries sampled or dropped by the sampling of module.
+# TYPE logzap_sampled_entries_total counter
+logzap_sampled_entries_total{decision="dropped",module="db.query"} 4
+logzap_sampled_entries_total{decision="sampled",module="db.query"} 4
+`), "logzap_entries_total", "logzap_sampled_entries_total"))
+
+	// the level changes keep the counters of sampler
+	logger.SetLevel("db", zapcore.DebugLevel)
+	logger.SetLevelFor("http", zapcore.DebugLevel, time.Minute)
+	for i := 0; i < 3; i++ {
+		logger.Get("db.query").Info("query")
+	}
+	require.NoError(t, testutil.CollectAndCompare(registry, strings.NewReader(`
+# HELP logzap_sampled_entries_total Number of log entries sampled or dropped by the sampling of module.
+# TYPE logzap_sampled_entries_total counter
+logzap_sampled_entries_total{decision="dropped",module="db.query"} 6
+logzap_sampled_entries_total{decision="sampled",module="db.query"} 5
+`), "logzap_sampled_entries_total"))
+
+	// the sampling is removed by reload
+	require.NoError(t, logger.ReloadConfig(context.Background(), logzap.Config{
+		Level: zapcore.InfoLevel,
+		Cores: logzap.Cores{"buffer": "buffer://"},
+	}))
+	for i := 0; i < 8; i++ {
+		logger.Get("db.query").Info("query")
+	}
+	require.NoError(t, testutil.CollectAndCompare(registry, strings.NewReader(`
+# HELP logzap_sampled_entries_total Number of log entries sampled or dropped by the sampling of module.
+# TYPE logzap_sampled_entries_total counter
+logzap_sampled_entries_total{decision="dropped",module="db.query"} 6
+logzap_sampled_entries_total{decision="sampled",module="db.query"} 5
+`), "logzap_sampled_entries_total"))
+}
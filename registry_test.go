@@ -0,0 +1,116 @@
+package logzap_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/kiraxie/logzap"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRegistryConcurrency(t *testing.T) {
+	t.Parallel()
+	logger := logzap.New(context.Background(), prometheus.NewRegistry(), logzap.Config{Level: zapcore.InfoLevel})
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger.Use(core)
+
+	const workers, iterations, names = 16, 200, 8
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				log := logger.Get(fmt.Sprintf("module%d", j%names), zap.Fields(zap.Int("worker", i)))
+				log.Named("sub").With(zap.Int("j", j)).Debug("concurrent")
+				if j%50 == 0 {
+					logger.SetLevel(fmt.Sprintf("module%d", i%names), zapcore.DebugLevel)
+					logger.Reload(zapcore.InfoLevel, nil)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	logger.Reload(zapcore.ErrorLevel, logzap.ModulesLevel{"module1": zapcore.DebugLevel})
+	logs.TakeAll()
+	for i := 0; i < names; i++ {
+		logger.Get(fmt.Sprintf("module%d", i)).Named("sub").Warn("after")
+	}
+	entries := logs.TakeAll()
+	require.Len(t, entries, 1)
+	require.Equal(t, "module1.sub", entries[0].LoggerName)
+}
+
+func TestRegistryOptionsPerHandle(t *testing.T) {
+	t.Parallel()
+	logger := logzap.New(context.Background(), prometheus.NewRegistry(), logzap.Config{Level: zapcore.InfoLevel})
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger.Use(core)
+
+	withField := logger.Get("foo", zap.Fields(zap.String("handle", "a")))
+	plain := logger.Get("foo")
+	withField.Info("a")
+	plain.Info("b")
+	withField.Named("sub").Info("c")
+
+	entries := logs.TakeAll()
+	require.Len(t, entries, 3)
+	require.Equal(t, map[string]interface{}{"handle": "a"}, entries[0].ContextMap())
+	require.Empty(t, entries[1].ContextMap())
+	require.Equal(t, map[string]interface{}{"handle": "a"}, entries[2].ContextMap())
+	require.Equal(t, "foo.sub", entries[2].LoggerName)
+}
+
+func BenchmarkGet(b *testing.B) {
+	logger := logzap.New(context.Background(), prometheus.NewRegistry(), logzap.Config{Level: zapcore.InfoLevel})
+	names := make([]string, 64)
+	for i := range names {
+		names[i] = fmt.Sprintf("module%d", i)
+		logger.Get(names[i])
+	}
+
+	b.Run("existing", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				_ = logger.Get(names[i%len(names)])
+				i++
+			}
+		})
+	})
+	b.Run("contended reload", func(b *testing.B) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			for ctx.Err() == nil {
+				logger.SetLevel(names[0], zapcore.DebugLevel)
+			}
+		}()
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				_ = logger.Get(names[i%len(names)])
+				i++
+			}
+		})
+	})
+	b.Run("new", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				_ = logger.Get(fmt.Sprintf("new%d", i))
+				i++
+			}
+		})
+	})
+}
@@ -0,0 +1,195 @@
+package logzap
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// standard query parameters understood by all cores built through Cores
+const (
+	queryLevel     = "level"
+	queryModules   = "modules"
+	queryExclude   = "exclude"
+	queryDedup     = "dedup"
+	queryDedupKeys = "dedupkeys"
+)
+
+// routeCore drops entries below the level or from modules which are not routed
+// to the wrapped core.
+type routeCore struct {
+	zapcore.Core
+	level   zapcore.LevelEnabler
+	include []string
+	exclude []string
+}
+
+// newRouteCore parses and removes the standard query parameters of rawURL,
+// the returned wrap is nil if none of them is present.
+func newRouteCore(rawURL string) (string, func(zapcore.Core) zapcore.Core, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL, nil, nil
+	}
+	q := u.Query()
+	if !q.Has(queryLevel) && !q.Has(queryModules) && !q.Has(queryExclude) && !q.Has(queryDedup) &&
+		!q.Has(queryDedupKeys) {
+		return rawURL, nil, nil
+	}
+	var window time.Duration
+	if d := q.Get(queryDedup); d != "" {
+		if window, err = time.ParseDuration(d); err != nil {
+			return "", nil, err
+		}
+		if window <= 0 {
+			return "", nil, fmt.Errorf("invalid dedup window %q", d)
+		}
+	}
+	var dedupKeys []string
+	for _, k := range strings.Split(q.Get(queryDedupKeys), ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			dedupKeys = append(dedupKeys, k)
+		}
+	}
+	if dedupKeys != nil && window == 0 {
+		return "", nil, fmt.Errorf("dedupkeys without dedup window")
+	}
+	route := routeCore{level: zap.LevelEnablerFunc(func(zapcore.Level) bool { return true })}
+	if lv := q.Get(queryLevel); lv != "" {
+		var level zapcore.Level
+		if err := level.UnmarshalText([]byte(lv)); err != nil {
+			return "", nil, err
+		}
+		route.level = level
+	}
+	route.include = splitModules(q.Get(queryModules))
+	route.exclude = splitModules(q.Get(queryExclude))
+	q.Del(queryLevel)
+	q.Del(queryModules)
+	q.Del(queryExclude)
+	q.Del(queryDedup)
+	q.Del(queryDedupKeys)
+	u.RawQuery = q.Encode()
+
+	return u.String(), func(core zapcore.Core) zapcore.Core {
+		if window > 0 {
+			core = newDedupCore(core, window, dedupKeys)
+		}
+		r := route
+		r.Core = core
+
+		return &r
+	}, nil
+}
+
+// withLevel drops the entries which are not enabled by lv, unlike
+// zap.IncreaseLevel it can be lower than the level of the wrapped core.
+func withLevel(lv zapcore.LevelEnabler) zap.Option {
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &routeCore{Core: core, level: lv}
+	})
+}
+
+func (t *routeCore) Enabled(lv zapcore.Level) bool {
+	return t.level.Enabled(lv) && t.Core.Enabled(lv)
+}
+
+func (t *routeCore) With(fields []zapcore.Field) zapcore.Core {
+	r := *t
+	r.Core = t.Core.With(fields)
+
+	return &r
+}
+
+func (t *routeCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !t.level.Enabled(ent.Level) || !t.routed(ent.LoggerName) {
+		return ce
+	}
+
+	return t.Core.Check(ent, ce)
+}
+
+func (t *routeCore) Flush(ctx context.Context) error {
+	return flushCore(ctx, t.Core)
+}
+
+func (t *routeCore) Close(ctx context.Context) error {
+	return closeCore(ctx, t.Core)
+}
+
+func (t *routeCore) routed(name string) bool {
+	for _, pattern := range t.exclude {
+		if matchModule(pattern, name) {
+			return false
+		}
+	}
+	if len(t.include) == 0 {
+		return true
+	}
+	for _, pattern := range t.include {
+		if matchModule(pattern, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func splitModules(s string) (modules []string) {
+	for _, m := range strings.Split(s, ",") {
+		if m = strings.ToLower(strings.TrimSpace(m)); m != "" {
+			modules = append(modules, m)
+		}
+	}
+
+	return
+}
+
+// matchModule reports whether the module or one of its ancestors is the
+// pattern, or matches the pattern as a glob of path.Match.
+func matchModule(pattern, name string) bool {
+	name = strings.ToLower(name)
+	for {
+		if name == pattern {
+			return true
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return false
+		}
+		name = name[:i]
+	}
+}
+
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[\`)
+}
+
+// literalLen return the number of characters which are not wildcards.
+func literalLen(pattern string) (n int) {
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*', '?':
+		case '[':
+			if j := strings.IndexByte(pattern[i:], ']'); j > 0 {
+				i += j
+			}
+		case '\\':
+			i++
+			n++
+		default:
+			n++
+		}
+	}
+
+	return
+}
@@ -0,0 +1,188 @@
+// Package slogzap implements a log/slog.Handler which writes through a logzap
+// module, so the module levels and Reload control the slog output too. A group
+// opens a submodule, such as "thirdparty.pool" of WithGroup("pool").
+package slogzap
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"github.com/kiraxie/logzap"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// New return a slog.Logger which writes to the module of the global instance.
+func New(module string) *slog.Logger {
+	return slog.New(NewHandler(logzap.Get(module)))
+}
+
+// NewHandler return a slog.Handler which writes to the logger, the record
+// keeps its own time and caller.
+func NewHandler(log logzap.Logger) *Handler {
+	return &Handler{log: log}
+}
+
+// Handler is a slog.Handler backed by a logzap.Logger.
+//
+// WithGroup derives the logger of submodule named by the group. The
+// attributes added out of any group are carried by the logger as zap fields,
+// the groups are still encoded as nested zap.Object when the record is handled
+// as slog requires.
+type Handler struct {
+	log    logzap.Logger
+	groups []group
+}
+
+// group is a group opened by WithGroup and the attributes added to it.
+type group struct {
+	name  string
+	attrs []slog.Attr
+}
+
+// Level return the zap level of slog level, the levels between the standard
+// ones are rounded down.
+func Level(lv slog.Level) zapcore.Level {
+	switch {
+	case lv < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case lv < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case lv < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+func (t *Handler) Enabled(_ context.Context, lv slog.Level) bool {
+	return t.log.Enabled(Level(lv))
+}
+
+func (t *Handler) Handle(ctx context.Context, r slog.Record) error {
+	ce := t.log.L().Check(Level(r.Level), r.Message)
+	if ce == nil {
+		return nil
+	}
+	ce.Time = r.Time
+	ce.Caller = zapcore.EntryCaller{}
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		ce.Caller = zapcore.EntryCaller{
+			Defined:  true,
+			PC:       frame.PC,
+			File:     frame.File,
+			Line:     frame.Line,
+			Function: frame.Function,
+		}
+	}
+
+	fields := make([]zap.Field, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, a)
+
+		return true
+	})
+	for i := len(t.groups) - 1; i >= 0; i-- {
+		g := t.groups[i]
+		inner := make([]zap.Field, 0, len(g.attrs)+len(fields))
+		for _, a := range g.attrs {
+			inner = appendAttr(inner, a)
+		}
+		fields = append(inner, fields...)
+		if len(fields) > 0 {
+			fields = []zap.Field{zap.Object(g.name, objectFields(fields))}
+		}
+	}
+	ce.Write(append(logzap.ContextFields(ctx), fields...)...)
+
+	return nil
+}
+
+func (t *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return t
+	}
+	h := &Handler{log: t.log, groups: t.groups}
+	if len(t.groups) == 0 {
+		fields := make([]zap.Field, 0, len(attrs))
+		for _, a := range attrs {
+			fields = appendAttr(fields, a)
+		}
+		h.log = t.log.With(fields...)
+
+		return h
+	}
+	h.groups = append([]group(nil), t.groups...)
+	last := &h.groups[len(h.groups)-1]
+	last.attrs = append(last.attrs[:len(last.attrs):len(last.attrs)], attrs...)
+
+	return h
+}
+
+func (t *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return t
+	}
+
+	return &Handler{
+		log:    t.log.Named(name),
+		groups: append(t.groups[:len(t.groups):len(t.groups)], group{name: name}),
+	}
+}
+
+// objectFields encodes the fields of a group.
+type objectFields []zap.Field
+
+func (t objectFields) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, f := range t {
+		f.AddTo(enc)
+	}
+
+	return nil
+}
+
+// appendAttr converts the attribute by its kind, the empty attributes and
+// groups are dropped and the groups without key are inlined.
+func appendAttr(fields []zap.Field, a slog.Attr) []zap.Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return append(fields, zap.String(a.Key, a.Value.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(a.Key, a.Value.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(a.Key, a.Value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(a.Key, a.Value.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(a.Key, a.Value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(a.Key, a.Value.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(a.Key, a.Value.Time()))
+	case slog.KindGroup:
+		var group []zap.Field
+		for _, attr := range a.Value.Group() {
+			group = appendAttr(group, attr)
+		}
+		if len(group) == 0 {
+			return fields
+		}
+		if a.Key == "" {
+			return append(fields, group...)
+		}
+
+		return append(fields, zap.Object(a.Key, objectFields(group)))
+	default:
+		if err, ok := a.Value.Any().(error); ok {
+			return append(fields, zap.NamedError(a.Key, err))
+		}
+
+		return append(fields, zap.Any(a.Key, a.Value.Any()))
+	}
+}
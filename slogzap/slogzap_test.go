@@ -0,0 +1,89 @@
+package slogzap_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/slogtest"
+	"time"
+
+	"github.com/kiraxie/logzap"
+	"github.com/kiraxie/logzap/slogzap"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func newLogzap(buf *bytes.Buffer) *logzap.Logzap {
+	logger := logzap.Nop()
+	logger.Use(zapcore.NewCore(zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+		TimeKey:        slog.TimeKey,
+		LevelKey:       slog.LevelKey,
+		MessageKey:     slog.MessageKey,
+		CallerKey:      slog.SourceKey,
+		EncodeLevel:    zapcore.CapitalLevelEncoder,
+		EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeCaller:   zapcore.FullCallerEncoder,
+	}), zapcore.AddSync(buf), zapcore.DebugLevel))
+	logger.SetLevel("", zapcore.DebugLevel)
+
+	return logger
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	logger := newLogzap(&buf)
+
+	err := slogtest.TestHandler(slogzap.NewHandler(logger.Get("slog")), func() (results []map[string]any) {
+		for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+			m := map[string]any{}
+			require.NoError(t, json.Unmarshal(line, &m))
+			// zap encoders always encode the time, the zero one is the one ignored
+			if m[slog.TimeKey] == (time.Time{}).Format(time.RFC3339Nano) {
+				delete(m, slog.TimeKey)
+			}
+			results = append(results, m)
+		}
+
+		return
+	})
+	require.NoError(t, err)
+}
+
+func TestModuleLevel(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	logger := newLogzap(&buf)
+	log := slog.New(slogzap.NewHandler(logger.Get("db")))
+
+	log.Debug("debug", "n", 1)
+	require.Contains(t, buf.String(), `"msg":"debug","n":1`)
+	source, _, _ := strings.Cut(decode(t, buf.Bytes())[slog.SourceKey].(string), ":")
+	require.Equal(t, "slogzap_test.go", filepath.Base(source))
+
+	buf.Reset()
+	logger.SetLevel("db", zapcore.WarnLevel)
+	log.Info("info")
+	require.False(t, log.Enabled(context.Background(), slog.LevelInfo))
+	require.Empty(t, buf.String())
+	log.Warn("warn")
+	require.Equal(t, "warn", decode(t, buf.Bytes())[slog.MessageKey])
+
+	// a group is a submodule, which is more verbose than db here
+	buf.Reset()
+	logger.SetLevel("db.pool", zapcore.DebugLevel)
+	log.WithGroup("pool").Info("info", "n", 1)
+	require.Equal(t, map[string]any{"n": float64(1)}, decode(t, buf.Bytes())["pool"])
+}
+
+func decode(t *testing.T, b []byte) map[string]any {
+	m := map[string]any{}
+	require.NoError(t, json.Unmarshal(bytes.TrimSpace(b), &m))
+
+	return m
+}
@@ -0,0 +1,45 @@
+package logzap
+
+import (
+	"bytes"
+	"log"
+	"runtime"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// RedirectStdLog redirects the output of the standard log package to module at
+// the level, and return a function to restore the previous output, flags and
+// prefix. The entries follow the level and cores of module across reloads.
+func (t *Logzap) RedirectStdLog(module string, lv zapcore.Level) func() {
+	flags, prefix, output := log.Flags(), log.Prefix(), log.Writer()
+	log.SetFlags(0)
+	log.SetPrefix("")
+	log.SetOutput(&stdLogWriter{log: t.Get(module), level: lv})
+
+	return func() {
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+		log.SetOutput(output)
+	}
+}
+
+// stdLogWriter writes the lines of the standard log package as entries.
+type stdLogWriter struct {
+	log   Logger
+	level zapcore.Level
+}
+
+// stdLogDepth is the frames from Write to the caller of log.Printf and alike.
+const stdLogDepth = 3
+
+func (t *stdLogWriter) Write(p []byte) (int, error) {
+	if ce := t.log.L().Check(t.level, string(bytes.TrimSuffix(p, []byte("\n")))); ce != nil {
+		if ce.Caller.Defined {
+			ce.Caller = zapcore.NewEntryCaller(runtime.Caller(stdLogDepth))
+		}
+		ce.Write()
+	}
+
+	return len(p), nil
+}
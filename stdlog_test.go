@@ -0,0 +1,42 @@
+package logzap_test
+
+import (
+	"bytes"
+	"log"
+	"path/filepath"
+	"testing"
+
+	"github.com/kiraxie/logzap"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestRedirectStdLog must not run in parallel since it changes the standard logger.
+func TestRedirectStdLog(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := logzap.Nop()
+	logger.Use(core)
+
+	var buf bytes.Buffer
+	output := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(output)
+	undo := logger.RedirectStdLog("stdlog", zapcore.WarnLevel)
+	log.Printf("hello %s", "world")
+	log.Println("line")
+	logger.SetLevel("stdlog", zapcore.ErrorLevel)
+	log.Print("dropped")
+	undo()
+	log.Print("restored")
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 2)
+	require.Equal(t, "hello world", entries[0].Message)
+	require.Equal(t, "stdlog", entries[0].LoggerName)
+	require.Equal(t, zapcore.WarnLevel, entries[0].Level)
+	require.Equal(t, "stdlog_test.go", filepath.Base(entries[0].Caller.File))
+	require.Equal(t, "line", entries[1].Message)
+	require.Contains(t, buf.String(), "restored")
+	require.Equal(t, log.LstdFlags, log.Flags())
+}
@@ -0,0 +1,221 @@
+package logzap
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WatchOptions configures Watch.
+type WatchOptions struct {
+	// Decode decodes the file content, DecodeConfig by default.
+	Decode func([]byte) (Config, error)
+	// Logger reports the changes and rejected files, module "logzap.watch" of
+	// the watched instance by default.
+	Logger Logger
+	// Debounce coalesces the file events within the duration, 100ms by default.
+	Debounce time.Duration
+}
+
+// Watch applies the configuration file to the instance and keeps watching it
+// until ctx is done. The file is re-decoded and validated on every change,
+// an invalid file is rejected and the previous configuration is kept.
+//
+// The directory of path is watched instead of the file itself, so that atomic
+// replacement by editors and symlink swapping of mounted volumes are observed.
+func (t *Logzap) Watch(ctx context.Context, path string, opts WatchOptions) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if opts.Decode == nil {
+		opts.Decode = DecodeConfig
+	}
+	if opts.Logger == nil {
+		opts.Logger = t.Get("logzap.watch")
+	}
+	if opts.Debounce <= 0 {
+		opts.Debounce = 100 * time.Millisecond
+	}
+	raw, c, err := loadConfig(path, opts.Decode)
+	if err != nil {
+		return err
+	}
+	if err = t.ReloadConfig(ctx, c); err != nil {
+		return err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err = watcher.Add(filepath.Dir(path)); err != nil {
+		_ = watcher.Close()
+
+		return err
+	}
+	go t.watch(ctx, watcher, path, raw, opts)
+
+	return nil
+}
+
+func (t *Logzap) watch(ctx context.Context, watcher *fsnotify.Watcher, path string, raw []byte, opts WatchOptions) {
+	defer watcher.Close()
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			opts.Logger.Trace(err, zap.String("path", path))
+		case _, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			debounce = time.After(opts.Debounce)
+		case <-debounce:
+			debounce = nil
+			raw = t.reloadFile(ctx, path, raw, opts)
+		}
+	}
+}
+
+// reloadFile applies the file if its content differs from last, and return
+// the content applied.
+func (t *Logzap) reloadFile(ctx context.Context, path string, last []byte, opts WatchOptions) []byte {
+	raw, c, err := loadConfig(path, opts.Decode)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		// the file is being replaced
+		return last
+	case err != nil:
+		opts.Logger.Error("config rejected", zap.String("path", path), zap.Error(err))
+
+		return last
+	case bytes.Equal(raw, last):
+		return last
+	}
+	old := t.Config()
+	if err = t.ReloadConfig(ctx, c); err != nil {
+		opts.Logger.Error("config rejected", zap.String("path", path), zap.Error(err))
+
+		return last
+	}
+	opts.Logger.Info("config reloaded", zap.String("path", path), zap.Object("diff", newConfigDiff(old, t.Config())))
+
+	return raw
+}
+
+func loadConfig(path string, decode func([]byte) (Config, error)) ([]byte, Config, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, Config{}, err
+	}
+	c, err := decode(raw)
+	if err == nil {
+		err = c.Validate()
+	}
+
+	return raw, c, err
+}
+
+// configDiff is the structured difference between two configurations, the
+// URLs of cores are left out as they may contain credentials.
+type configDiff struct {
+	old, new Config
+}
+
+func newConfigDiff(old, new Config) zapcore.ObjectMarshaler {
+	return configDiff{old: old, new: new}
+}
+
+func (t configDiff) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if t.old.Level != t.new.Level {
+		_ = enc.AddObject("level", levelDiff{t.old.Level, t.new.Level, true, true})
+	}
+	modules := zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		for _, name := range unionKeys(t.old.Modules, t.new.Modules) {
+			old, hasOld := t.old.Modules[name]
+			lv, hasNew := t.new.Modules[name]
+			if hasOld != hasNew || old != lv {
+				_ = enc.AddObject(name, levelDiff{old, lv, hasOld, hasNew})
+			}
+		}
+
+		return nil
+	})
+	_ = enc.AddObject("modules", modules)
+	var added, removed, changed stringArray
+	for _, name := range unionKeys(t.old.Cores, t.new.Cores) {
+		old, hasOld := t.old.Cores[name]
+		url, hasNew := t.new.Cores[name]
+		switch {
+		case !hasOld:
+			added = append(added, name)
+		case !hasNew:
+			removed = append(removed, name)
+		case old != url:
+			changed = append(changed, name)
+		}
+	}
+	_ = enc.AddObject("cores", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		_ = enc.AddArray("added", added)
+		_ = enc.AddArray("removed", removed)
+		_ = enc.AddArray("changed", changed)
+
+		return nil
+	}))
+
+	return nil
+}
+
+type stringArray []string
+
+func (t stringArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, s := range t {
+		enc.AppendString(s)
+	}
+
+	return nil
+}
+
+type levelDiff struct {
+	old, new       zapcore.Level
+	hasOld, hasNew bool
+}
+
+func (t levelDiff) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if t.hasOld {
+		enc.AddString("old", t.old.String())
+	}
+	if t.hasNew {
+		enc.AddString("new", t.new.String())
+	}
+
+	return nil
+}
+
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
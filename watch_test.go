@@ -0,0 +1,88 @@
+package logzap_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kiraxie/logzap"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestDecodeConfig(t *testing.T) {
+	t.Parallel()
+	c, err := logzap.DecodeConfig([]byte(`
+level: warn
+modules:
+  foo: debug
+  parent:
+    child: error
+cores:
+  stdout: "console://?level=info"
+`))
+	require.NoError(t, err)
+	require.NoError(t, c.Validate())
+	require.Equal(t, zapcore.WarnLevel, c.Level)
+	require.Equal(t, logzap.ModulesLevel{"foo": zapcore.DebugLevel, "parent.child": zapcore.ErrorLevel}, c.Modules)
+	require.Equal(t, logzap.Cores{"stdout": "console://?level=info"}, c.Cores)
+
+	_, err = logzap.DecodeConfig([]byte("level: verbose"))
+	require.Error(t, err)
+	_, err = logzap.DecodeConfig([]byte("levle: info"))
+	require.Error(t, err)
+
+	c, err = logzap.DecodeConfig([]byte("cores: {foo: \"unknown://\"}"))
+	require.NoError(t, err)
+	require.ErrorIs(t, c.Validate(), logzap.ErrUnsupportedCoreConstructor)
+	c, err = logzap.DecodeConfig([]byte("cores: {foo: \"console://?level=verbose\"}"))
+	require.NoError(t, err)
+	require.Error(t, c.Validate())
+}
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "logzap.yaml")
+	write := func(s string) {
+		tmp := path + ".tmp"
+		require.NoError(t, os.WriteFile(tmp, []byte(s), 0o600))
+		require.NoError(t, os.Rename(tmp, path))
+	}
+	write("level: info\nmodules:\n  foo: warn\n")
+
+	logger := logzap.New(context.Background(), prometheus.NewRegistry(), logzap.Config{})
+	b := &lockedBuffer{}
+	watchLogger := logzap.New(context.Background(), prometheus.NewRegistry(), logzap.Config{})
+	watchLogger.Use(b)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, logger.Watch(ctx, path, logzap.WatchOptions{
+		Logger:   watchLogger.Get("watch"),
+		Debounce: 10 * time.Millisecond,
+	}))
+	foo := logger.Get("foo")
+	require.False(t, foo.Enabled(zapcore.InfoLevel))
+	require.True(t, logger.Get("boo").Enabled(zapcore.InfoLevel))
+
+	write("level: info\nmodules:\n  foo: debug\n")
+	require.Eventually(t, func() bool {
+		return foo.Enabled(zapcore.DebugLevel)
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		return b.String() != ""
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Contains(t, b.String(), "config reloaded")
+
+	write("level: info\nmodules:\n  foo: verbose\n")
+	require.Eventually(t, func() bool {
+		return len(b.String()) > len("config reloaded\n")
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Contains(t, b.String(), "config rejected")
+	require.True(t, foo.Enabled(zapcore.DebugLevel))
+
+	require.Error(t, logger.Watch(ctx, filepath.Join(t.TempDir(), "absent.yaml"), logzap.WatchOptions{}))
+}